@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+var ErrInvalidInput = errors.New("some statements could not be parsed")
+
+// RunCLI reads the DSL from in, prints a table of areas and perimeters to out and parse errors to errOut.
+// Taking readers and writers rather than os.Stdin and os.Stdout keeps it testable, just like Greet in the DI chapter.
+func RunCLI(in io.Reader, out, errOut io.Writer) error {
+	input, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+
+	shapes, parseErrors := ParseShapes(string(input))
+	report := Measure(shapes)
+
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "shape\tarea\tperimeter")
+	for _, m := range report.Shapes {
+		fmt.Fprintf(table, "%s\t%.2f\t%.2f\n", m.Shape, m.Area, m.Perimeter)
+	}
+	fmt.Fprintf(table, "total\t%.2f\t%.2f\n", report.TotalArea, report.TotalPerimeter)
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	for _, parseErr := range parseErrors {
+		fmt.Fprintln(errOut, parseErr)
+	}
+	if len(parseErrors) > 0 {
+		return ErrInvalidInput
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunCLI(t *testing.T) {
+	t.Run("prints each shape and the totals", func(t *testing.T) {
+		out := bytes.Buffer{}
+		errOut := bytes.Buffer{}
+
+		err := RunCLI(strings.NewReader("rect 3x4\nrect 2x2"), &out, &errOut)
+
+		if err != nil {
+			t.Fatalf("didn't want an error, got %v", err)
+		}
+		want := "shape     area   perimeter\n" +
+			"rect 3x4  12.00  14.00\n" +
+			"rect 2x2  4.00   8.00\n" +
+			"total     16.00  22.00\n"
+		if out.String() != want {
+			t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+		}
+		if errOut.Len() != 0 {
+			t.Errorf("didn't want anything on errOut, got %q", errOut.String())
+		}
+	})
+
+	t.Run("reports bad lines and still totals the rest", func(t *testing.T) {
+		out := bytes.Buffer{}
+		errOut := bytes.Buffer{}
+
+		err := RunCLI(strings.NewReader("rect 3x4\nsquare 2"), &out, &errOut)
+
+		if err != ErrInvalidInput {
+			t.Errorf("got error %v want %v", err, ErrInvalidInput)
+		}
+		if !strings.Contains(out.String(), "total     12.00") {
+			t.Errorf("expected the good line in the total, got\n%s", out.String())
+		}
+		if !strings.HasPrefix(errOut.String(), `line 2: "square 2"`) {
+			t.Errorf("got errOut %q", errOut.String())
+		}
+	})
+}
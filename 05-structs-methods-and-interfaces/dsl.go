@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A tiny text format so people can paste room dimensions instead of writing JSON.
+// Statements are separated by semicolons or new lines:
+//
+//	rect 3x4; circle r=2
+//	tri b=3 h=5
+//
+// rect also accepts w= and h=, and rectangle/triangle work as long names.
+
+// ParseError tells you which statement went wrong and on which line,
+// so a single typo doesn't throw away the rest of the input.
+type ParseError struct {
+	Line      int    `json:"line"`
+	Statement string `json:"statement"`
+	Err       error  `json:"-"`
+}
+
+func (e ParseError) Error() string {
+	return fmt.Sprintf("line %d: %q: %v", e.Line, e.Statement, e.Err)
+}
+
+func (e ParseError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	ErrUnknownShape    = errors.New("unknown shape")
+	ErrMissingArgument = errors.New("missing argument")
+	ErrBadArgument     = errors.New("bad argument")
+	ErrBadDimension    = errors.New("dimensions must be positive numbers")
+)
+
+// ParseShapes returns every shape it could read along with an error for each statement it couldn't.
+func ParseShapes(input string) ([]Shape, []ParseError) {
+	var shapes []Shape
+	var parseErrors []ParseError
+
+	for i, line := range strings.Split(input, "\n") {
+		for _, statement := range strings.Split(line, ";") {
+			statement = strings.TrimSpace(statement)
+			if statement == "" || strings.HasPrefix(statement, "#") {
+				continue
+			}
+
+			shape, err := parseStatement(statement)
+			if err != nil {
+				parseErrors = append(parseErrors, ParseError{Line: i + 1, Statement: statement, Err: err})
+				continue
+			}
+			shapes = append(shapes, shape)
+		}
+	}
+
+	return shapes, parseErrors
+}
+
+func parseStatement(statement string) (Shape, error) {
+	fields := strings.Fields(statement)
+	kind, args := strings.ToLower(fields[0]), fields[1:]
+
+	switch kind {
+	case "rect", "rectangle":
+		// the short form is WIDTHxHEIGHT, e.g. rect 3x4
+		if len(args) == 1 && !strings.Contains(args[0], "=") {
+			width, height, ok := strings.Cut(strings.ToLower(args[0]), "x")
+			if !ok {
+				return nil, fmt.Errorf("%w: want WIDTHxHEIGHT, got %q", ErrBadArgument, args[0])
+			}
+			w, err := parseDimension("width", width)
+			if err != nil {
+				return nil, err
+			}
+			h, err := parseDimension("height", height)
+			if err != nil {
+				return nil, err
+			}
+			return Rectangle{Width: w, Height: h}, nil
+		}
+		dims, err := parseNamedDimensions(args, "w", "h")
+		if err != nil {
+			return nil, err
+		}
+		return Rectangle{Width: dims["w"], Height: dims["h"]}, nil
+	case "circle":
+		dims, err := parseNamedDimensions(args, "r")
+		if err != nil {
+			return nil, err
+		}
+		return Circle{Radius: dims["r"]}, nil
+	case "tri", "triangle":
+		dims, err := parseNamedDimensions(args, "b", "h")
+		if err != nil {
+			return nil, err
+		}
+		return Triangle{Base: dims["b"], Height: dims["h"]}, nil
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownShape, kind)
+	}
+}
+
+// parseNamedDimensions reads key=value arguments and makes sure we got exactly the keys we asked for.
+func parseNamedDimensions(args []string, keys ...string) (map[string]float64, error) {
+	dims := map[string]float64{}
+
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		key = strings.ToLower(key)
+		if !ok || !slices.Contains(keys, key) {
+			return nil, fmt.Errorf("%w %q, want %s=", ErrBadArgument, arg, strings.Join(keys, "= "))
+		}
+		if _, seen := dims[key]; seen {
+			return nil, fmt.Errorf("%w: %s given twice", ErrBadArgument, key)
+		}
+		n, err := parseDimension(key, value)
+		if err != nil {
+			return nil, err
+		}
+		dims[key] = n
+	}
+
+	for _, key := range keys {
+		if _, ok := dims[key]; !ok {
+			return nil, fmt.Errorf("%w %s=", ErrMissingArgument, key)
+		}
+	}
+
+	return dims, nil
+}
+
+func parseDimension(name, value string) (float64, error) {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || !validDimension(n) {
+		return 0, fmt.Errorf("%w: %s=%q", ErrBadDimension, name, value)
+	}
+	return n, nil
+}
+
+// !(n > 0) also catches NaN, which ParseFloat is happy to hand back
+func validDimension(n float64) bool {
+	return n > 0 && !math.IsInf(n, 1)
+}
+
+// Describe writes a shape back out in the same format we parse,
+// which keeps the CLI and the HTTP responses easy to match up with the input.
+func Describe(shape Shape) string {
+	switch s := shape.(type) {
+	case Rectangle:
+		return fmt.Sprintf("rect %sx%s", formatNumber(s.Width), formatNumber(s.Height))
+	case Circle:
+		return fmt.Sprintf("circle r=%s", formatNumber(s.Radius))
+	case Triangle:
+		return fmt.Sprintf("tri b=%s h=%s", formatNumber(s.Base), formatNumber(s.Height))
+	default:
+		return fmt.Sprintf("%#v", shape)
+	}
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'g', -1, 64)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseShapes(t *testing.T) {
+	t.Run("parses every kind of statement", func(t *testing.T) {
+		input := "rect 3x4; circle r=2\ntri b=3 h=5\nrectangle w=1.5 h=2 ; triangle h=1 b=2"
+
+		got, parseErrors := ParseShapes(input)
+		want := []Shape{
+			Rectangle{3, 4},
+			Circle{2},
+			Triangle{3, 5},
+			Rectangle{1.5, 2},
+			Triangle{2, 1},
+		}
+
+		if len(parseErrors) != 0 {
+			t.Fatalf("didn't want errors, got %v", parseErrors)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v want %v", got, want)
+		}
+	})
+
+	t.Run("skips blank statements and comments", func(t *testing.T) {
+		got, parseErrors := ParseShapes("\n# kitchen\nrect 2x2;;\n")
+
+		if len(parseErrors) != 0 {
+			t.Fatalf("didn't want errors, got %v", parseErrors)
+		}
+		if !reflect.DeepEqual(got, []Shape{Rectangle{2, 2}}) {
+			t.Errorf("got %v", got)
+		}
+	})
+
+	t.Run("reports errors per line and keeps the good shapes", func(t *testing.T) {
+		input := "rect 3x4\nhexagon s=2; circle r=-1\ncircle\nrect 3by4\ntri b=3 h=5 h=6\ncircle r=NaN"
+
+		got, parseErrors := ParseShapes(input)
+
+		if !reflect.DeepEqual(got, []Shape{Rectangle{3, 4}}) {
+			t.Errorf("got shapes %v", got)
+		}
+
+		wantErrors := []struct {
+			line int
+			err  error
+		}{
+			{2, ErrUnknownShape},
+			{2, ErrBadDimension},
+			{3, ErrMissingArgument},
+			{4, ErrBadArgument},
+			{5, ErrBadArgument},
+			{6, ErrBadDimension},
+		}
+
+		if len(parseErrors) != len(wantErrors) {
+			t.Fatalf("got %d errors want %d: %v", len(parseErrors), len(wantErrors), parseErrors)
+		}
+		for i, want := range wantErrors {
+			if parseErrors[i].Line != want.line {
+				t.Errorf("error %d: got line %d want %d", i, parseErrors[i].Line, want.line)
+			}
+			if !errors.Is(parseErrors[i], want.err) {
+				t.Errorf("error %d: got %v want %v", i, parseErrors[i], want.err)
+			}
+		}
+	})
+}
+
+func TestDescribe(t *testing.T) {
+	for _, input := range []string{"rect 3x4", "circle r=2.5", "tri b=3 h=5"} {
+		shapes, _ := ParseShapes(input)
+		got := Describe(shapes[0])
+		if got != input {
+			t.Errorf("got %q want %q", got, input)
+		}
+	}
+}
@@ -1,6 +1,13 @@
 package main
 
-import "math"
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+)
 
 // TYPES ----------
 
@@ -41,6 +48,22 @@ func (t Triangle) Area() float64 {
 	return (t.Base * t.Height) * 0.5
 }
 
+// Every shape also knows its own perimeter now, so the HTTP service and the CLI can ask for both.
+func (r Rectangle) Perimeter() float64 {
+	return 2 * (r.Width + r.Height)
+}
+
+func (c Circle) Perimeter() float64 {
+	return 2 * math.Pi * c.Radius
+}
+
+// A base and a height don't pin down a triangle's sides,
+// so we treat it as isosceles: the apex sits above the middle of the base.
+func (t Triangle) Perimeter() float64 {
+	side := math.Hypot(t.Base/2, t.Height)
+	return t.Base + 2*side
+}
+
 // INTERFACES ----------
 
 // We tell Go what a Shape is using an interface declaration
@@ -60,6 +83,7 @@ func (t Triangle) Area() float64 {
 
 type Shape interface {
 	Area() float64
+	Perimeter() float64
 }
 
 // ----------
@@ -72,3 +96,24 @@ func Perimeter(rectangle Rectangle) float64 {
 // func Area(rectangle Rectangle) float64 {
 // 	return rectangle.Width * rectangle.Height
 // }
+
+// ----------
+
+// With no flags we read the DSL from stdin, so you can paste dimensions straight in:
+//
+//	echo "rect 3x4; circle r=2" | go run .
+//
+// With -addr we serve the same thing over HTTP instead.
+func main() {
+	addr := flag.String("addr", "", "serve the shapes API on this address, e.g. :5002")
+	flag.Parse()
+
+	if *addr != "" {
+		log.Fatal(http.ListenAndServe(*addr, NewShapeServer()))
+	}
+
+	if err := RunCLI(os.Stdin, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -113,3 +113,24 @@ func TestAreaTableDrivenImproved(t *testing.T) {
 // We are now starting to define our own types. In statically typed languages like Go, being able to design your own types is essential for building software that is easy to understand, to piece together and to test.
 
 // Interfaces are a great tool for hiding complexity away from other parts of the system.
+
+func TestPerimeterMethods(t *testing.T) {
+	perimeterTests := []struct {
+		name         string
+		shape        Shape
+		hasPerimeter float64
+	}{
+		{name: "Rectangle", shape: Rectangle{Width: 10, Height: 10}, hasPerimeter: 40.0},
+		{name: "Circle", shape: Circle{Radius: 10}, hasPerimeter: 62.83185307179586},
+		{name: "Triangle", shape: Triangle{Base: 6, Height: 4}, hasPerimeter: 16.0},
+	}
+
+	for _, tt := range perimeterTests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.shape.Perimeter()
+			if got != tt.hasPerimeter {
+				t.Errorf("%#v got %g want %g", tt.shape, got, tt.hasPerimeter)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Measurement is what we send back for every shape: what it was, its area and its perimeter.
+type Measurement struct {
+	Shape     string  `json:"shape"`
+	Area      float64 `json:"area"`
+	Perimeter float64 `json:"perimeter"`
+}
+
+type Report struct {
+	Shapes         []Measurement `json:"shapes"`
+	TotalArea      float64       `json:"totalArea"`
+	TotalPerimeter float64       `json:"totalPerimeter"`
+	Errors         []ParseError  `json:"errors,omitempty"`
+}
+
+// Measure only needs the Shape interface, it never has to know which concrete shapes it was given.
+func Measure(shapes []Shape) Report {
+	report := Report{Shapes: []Measurement{}}
+
+	for _, shape := range shapes {
+		m := Measurement{Shape: Describe(shape), Area: shape.Area(), Perimeter: shape.Perimeter()}
+		report.Shapes = append(report.Shapes, m)
+		report.TotalArea += m.Area
+		report.TotalPerimeter += m.Perimeter
+	}
+
+	return report
+}
+
+// Err is an error value so it can't be encoded on its own, so we spell the message out.
+func (e ParseError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Line      int    `json:"line"`
+		Statement string `json:"statement"`
+		Error     string `json:"error"`
+	}{e.Line, e.Statement, e.Err.Error()})
+}
+
+// ShapeSpec is one element of the JSON list we accept, e.g. {"type": "circle", "radius": 2}.
+type ShapeSpec struct {
+	Type   string  `json:"type"`
+	Width  float64 `json:"width,omitempty"`
+	Height float64 `json:"height,omitempty"`
+	Radius float64 `json:"radius,omitempty"`
+	Base   float64 `json:"base,omitempty"`
+}
+
+// Shape checks the dimensions with the same rules as the DSL, so both inputs accept exactly the same shapes.
+// A dimension left out of the JSON is zero, which isn't a valid dimension either.
+func (s ShapeSpec) Shape() (Shape, error) {
+	switch strings.ToLower(s.Type) {
+	case "rect", "rectangle":
+		if err := checkDimensions(dimension{"w", s.Width}, dimension{"h", s.Height}); err != nil {
+			return nil, err
+		}
+		return Rectangle{Width: s.Width, Height: s.Height}, nil
+	case "circle":
+		if err := checkDimensions(dimension{"r", s.Radius}); err != nil {
+			return nil, err
+		}
+		return Circle{Radius: s.Radius}, nil
+	case "tri", "triangle":
+		if err := checkDimensions(dimension{"b", s.Base}, dimension{"h", s.Height}); err != nil {
+			return nil, err
+		}
+		return Triangle{Base: s.Base, Height: s.Height}, nil
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownShape, s.Type)
+	}
+}
+
+// dimension is named like its DSL argument, so the errors read the same for both inputs.
+type dimension struct {
+	name  string
+	value float64
+}
+
+func checkDimensions(dims ...dimension) error {
+	for _, d := range dims {
+		if !validDimension(d.value) {
+			return fmt.Errorf("%w: %s=%q", ErrBadDimension, d.name, formatNumber(d.value))
+		}
+	}
+	return nil
+}
+
+// ParseShapeList does for JSON what ParseShapes does for the DSL.
+// For JSON the Line of a ParseError is the position of the element in the list.
+func ParseShapeList(r io.Reader) ([]Shape, []ParseError, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, nil, err
+	}
+
+	var shapes []Shape
+	var parseErrors []ParseError
+
+	for i, item := range raw {
+		var spec ShapeSpec
+		var shape Shape
+		err := json.Unmarshal(item, &spec)
+		if err == nil {
+			shape, err = spec.Shape()
+		}
+		if err != nil {
+			parseErrors = append(parseErrors, ParseError{Line: i + 1, Statement: string(item), Err: err})
+			continue
+		}
+		shapes = append(shapes, shape)
+	}
+
+	return shapes, parseErrors, nil
+}
+
+const jsonContentType = "application/json"
+
+// 1MB is far more than anyone's floor plan
+const maxBodySize = 1 << 20
+
+// ShapeServer follows the PlayerServer pattern: embed http.Handler and set the routes up once in the constructor.
+type ShapeServer struct {
+	http.Handler
+}
+
+func NewShapeServer() *ShapeServer {
+	s := new(ShapeServer)
+
+	router := http.NewServeMux()
+	router.Handle("POST /shapes", http.HandlerFunc(s.shapesHandler))
+
+	s.Handler = router
+	return s
+}
+
+// shapesHandler takes a JSON list when the client says it is sending JSON and the DSL otherwise.
+// If any statement couldn't be parsed we still measure the rest but answer 422 so the client notices.
+func (s *ShapeServer) shapesHandler(w http.ResponseWriter, r *http.Request) {
+	body := http.MaxBytesReader(w, r.Body, maxBodySize)
+
+	var shapes []Shape
+	var parseErrors []ParseError
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("content-type"))
+	if mediaType == jsonContentType {
+		var err error
+		shapes, parseErrors, err = ParseShapeList(body)
+		if err != nil {
+			writeError(w, readErrorStatus(err), fmt.Sprintf("could not decode shape list: %v", err))
+			return
+		}
+	} else {
+		input, err := io.ReadAll(body)
+		if err != nil {
+			writeError(w, readErrorStatus(err), err.Error())
+			return
+		}
+		shapes, parseErrors = ParseShapes(string(input))
+	}
+
+	report := Measure(shapes)
+	report.Errors = parseErrors
+
+	w.Header().Set("content-type", jsonContentType)
+	if len(parseErrors) > 0 {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+// readErrorStatus tells a body that was too big apart from one we couldn't read or decode,
+// whichever format it was sent in.
+func readErrorStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("content-type", jsonContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
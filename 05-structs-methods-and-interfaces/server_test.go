@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestShapeServer(t *testing.T) {
+	server := NewShapeServer()
+
+	t.Run("measures shapes written in the DSL", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, newShapesRequest("text/plain", "rect 3x4; rect 1x1"))
+
+		assertStatus(t, response.Code, http.StatusOK)
+		report := getReportFromResponse(t, response)
+		if len(report.Shapes) != 2 || report.TotalArea != 13 || report.TotalPerimeter != 18 {
+			t.Errorf("got %+v", report)
+		}
+		if report.Shapes[0] != (Measurement{"rect 3x4", 12, 14}) {
+			t.Errorf("got %+v", report.Shapes[0])
+		}
+	})
+
+	t.Run("measures shapes sent as a JSON list", func(t *testing.T) {
+		body := `[{"type": "rect", "width": 3, "height": 4}, {"type": "triangle", "base": 6, "height": 4}]`
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, newShapesRequest("application/json; charset=utf-8", body))
+
+		assertStatus(t, response.Code, http.StatusOK)
+		report := getReportFromResponse(t, response)
+		if report.TotalArea != 24 || report.TotalPerimeter != 30 {
+			t.Errorf("got %+v", report)
+		}
+	})
+
+	t.Run("returns 422 with per-line errors", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, newShapesRequest("text/plain", "rect 3x4\ncircle r=oops"))
+
+		assertStatus(t, response.Code, http.StatusUnprocessableEntity)
+
+		var got struct {
+			Shapes []Measurement
+			Errors []struct {
+				Line      int
+				Statement string
+				Error     string
+			}
+		}
+		if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Shapes) != 1 || len(got.Errors) != 1 {
+			t.Fatalf("got %+v", got)
+		}
+		if got.Errors[0].Line != 2 || got.Errors[0].Statement != "circle r=oops" || got.Errors[0].Error == "" {
+			t.Errorf("got %+v", got.Errors[0])
+		}
+	})
+
+	t.Run("returns 422 for bad JSON elements", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, newShapesRequest("application/json", `[{"type": "circle", "radius": 1}, {"type": "blob"}]`))
+
+		assertStatus(t, response.Code, http.StatusUnprocessableEntity)
+		report := getReportFromResponse(t, response)
+		if len(report.Shapes) != 1 {
+			t.Errorf("got %+v", report)
+		}
+	})
+
+	t.Run("returns 400 for JSON that isn't a list", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, newShapesRequest("application/json", `{"type": "circle"}`))
+
+		assertStatus(t, response.Code, http.StatusBadRequest)
+	})
+
+	t.Run("checks JSON dimensions like the DSL does", func(t *testing.T) {
+		body := `[{"type": "rect", "width": 3}, {"type": "circle", "radius": -1}, {"type": "tri", "base": 2, "height": 5}]`
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, newShapesRequest("application/json", body))
+
+		assertStatus(t, response.Code, http.StatusUnprocessableEntity)
+
+		var got struct {
+			Shapes []Measurement
+			Errors []struct{ Line int }
+		}
+		if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Shapes) != 1 || got.Shapes[0].Shape != "tri b=2 h=5" || got.Shapes[0].Area != 5 {
+			t.Errorf("got %+v", got.Shapes)
+		}
+		if len(got.Errors) != 2 || got.Errors[0].Line != 1 || got.Errors[1].Line != 2 {
+			t.Errorf("got %+v", got.Errors)
+		}
+	})
+
+	t.Run("returns 413 for a body that is too large, whatever its format", func(t *testing.T) {
+		cases := map[string]string{
+			"text/plain":       strings.Repeat("rect 3x4\n", maxBodySize/8),
+			"application/json": "[" + strings.Repeat(`{"type": "circle", "radius": 1},`, maxBodySize/30) + "]",
+		}
+
+		for contentType, body := range cases {
+			response := httptest.NewRecorder()
+			server.ServeHTTP(response, newShapesRequest(contentType, body))
+
+			if response.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("%s: got status %d want %d", contentType, response.Code, http.StatusRequestEntityTooLarge)
+			}
+		}
+	})
+
+	t.Run("only accepts POST", func(t *testing.T) {
+		request, _ := http.NewRequest(http.MethodGet, "/shapes", nil)
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, request)
+
+		assertStatus(t, response.Code, http.StatusMethodNotAllowed)
+	})
+}
+
+func newShapesRequest(contentType, body string) *http.Request {
+	request, _ := http.NewRequest(http.MethodPost, "/shapes", strings.NewReader(body))
+	request.Header.Set("content-type", contentType)
+	return request
+}
+
+func getReportFromResponse(t testing.TB, response *httptest.ResponseRecorder) (report Report) {
+	t.Helper()
+
+	if got := response.Result().Header.Get("content-type"); got != jsonContentType {
+		t.Errorf("response did not have content-type of %s, got %s", jsonContentType, got)
+	}
+
+	var got struct {
+		Shapes         []Measurement
+		TotalArea      float64
+		TotalPerimeter float64
+	}
+	if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+		t.Fatalf("Unable to parse response from server %q into Report, '%v'", response.Body, err)
+	}
+
+	return Report{Shapes: got.Shapes, TotalArea: got.TotalArea, TotalPerimeter: got.TotalPerimeter}
+}
+
+func assertStatus(t testing.TB, got, want int) {
+	t.Helper()
+	if got != want {
+		t.Errorf("did not get correct status, got %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Schedule pays interest or charges a fee on a wallet once every period.
+//
+// Each period is recorded in the wallet's ledger as an entry memo'd with the schedule's Name
+// and the time the period ended, even when the amount rounds to zero. The entry itself is
+// stamped with when it was applied, so the ledger stays in time order after catching up.
+// That entry is how we know a period has been dealt with: after downtime the scheduler
+// carries on from the last one it finds, so every missed period is applied exactly once.
+//
+// Amounts are worked out from the balance as it was when the period ended
+// (everything in the ledger from before then), not whatever it is by the time we
+// catch up, so running late gives exactly the same result as running on time. The one
+// exception is a fee, which is also capped at what the wallet has available now: money taken
+// out since the period ended can't be charged for again, money on hold is already spoken for,
+// and a fee never overdraws a wallet.
+type Schedule struct {
+	// Name must be unique among the schedules on a wallet
+	Name string
+	// Start is when the first period begins, it ends at Start+Every
+	Start time.Time
+	Every time.Duration
+	Rule  AccrualRule
+}
+
+const (
+	KindInterest = EntryKind("interest")
+	KindFee      = EntryKind("fee")
+)
+
+var ErrInvalidSchedule = errors.New("schedule needs a name, a rule and a period of more than zero")
+
+// AccrualRule works out the change for one period.
+// balance is the balance at the end of the period and principal is that balance minus
+// any interest this schedule has already paid, which is what simple interest is paid on.
+type AccrualRule interface {
+	Kind() EntryKind
+	Amount(balance, principal Bitcoin) Bitcoin
+}
+
+// Rates are in basis points, 1bp is 0.01%, so 250 is 2.5% per period.
+// All the arithmetic is on integers, with the rounding spelled out on each rule.
+
+// SimpleInterest pays on the principal only, interest never earns interest.
+// It rounds down to a whole unit, and nothing is paid on a balance of zero or less.
+type SimpleInterest struct {
+	BasisPoints int
+}
+
+func (SimpleInterest) Kind() EntryKind { return KindInterest }
+
+func (s SimpleInterest) Amount(balance, principal Bitcoin) Bitcoin {
+	return basisPointsRoundedDown(principal, s.BasisPoints)
+}
+
+// CompoundInterest pays on the whole balance, including interest paid in earlier periods.
+// It rounds down to a whole unit, and nothing is paid on a balance of zero or less.
+type CompoundInterest struct {
+	BasisPoints int
+}
+
+func (CompoundInterest) Kind() EntryKind { return KindInterest }
+
+func (c CompoundInterest) Amount(balance, principal Bitcoin) Bitcoin {
+	return basisPointsRoundedDown(balance, c.BasisPoints)
+}
+
+// FixedFee charges the same amount every period.
+// Like every fee it never takes more than the balance, so it can't push a wallet below zero.
+type FixedFee struct {
+	Fee Bitcoin
+}
+
+func (FixedFee) Kind() EntryKind { return KindFee }
+
+func (f FixedFee) Amount(balance, principal Bitcoin) Bitcoin {
+	return -capFee(f.Fee, balance)
+}
+
+// PercentageFee charges a share of the balance, rounding half a unit or more up.
+type PercentageFee struct {
+	BasisPoints int
+}
+
+func (PercentageFee) Kind() EntryKind { return KindFee }
+
+func (p PercentageFee) Amount(balance, principal Bitcoin) Bitcoin {
+	if balance <= 0 {
+		return 0
+	}
+	fee := (balance*Bitcoin(p.BasisPoints) + 5_000) / 10_000
+	return -capFee(fee, balance)
+}
+
+func basisPointsRoundedDown(amount Bitcoin, basisPoints int) Bitcoin {
+	if amount <= 0 {
+		return 0
+	}
+	return amount * Bitcoin(basisPoints) / 10_000
+}
+
+func capFee(fee, balance Bitcoin) Bitcoin {
+	return max(min(fee, balance), 0)
+}
+
+// Accrue applies every period of schedule that has ended by now and hasn't been applied yet,
+// returning the entries it recorded.
+func (w *Wallet) Accrue(schedule Schedule, now time.Time) ([]Entry, error) {
+	if err := schedule.validate(); err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	defer w.unlock()
+
+	at := w.accrualTime(now)
+	var recorded []Entry
+	for _, a := range w.dueAccruals(schedule, now) {
+		w.balance += a.amount
+		recorded = append(recorded, w.record(at, a.kind, a.amount, a.memo))
+	}
+	return recorded, nil
+}
+
+func (s Schedule) validate() error {
+	if s.Name == "" || s.Rule == nil || s.Every <= 0 {
+		return ErrInvalidSchedule
+	}
+	return nil
+}
+
+// accrual is one period's interest or fee, worked out but not applied yet.
+type accrual struct {
+	kind   EntryKind
+	amount Bitcoin
+	memo   string
+}
+
+// dueAccruals must be called with w.mu held. It works out every period of schedule that
+// has ended by now and hasn't been applied yet, without changing the wallet, so the
+// store can log them before applying them.
+func (w *Wallet) dueAccruals(schedule Schedule, now time.Time) []accrual {
+	kind := schedule.Rule.Kind()
+	w.expireHolds(now)
+
+	// pick up where the last recorded period left off
+	periodEnd := schedule.Start.Add(schedule.Every)
+	for _, e := range w.ledger {
+		if name, end, ok := accrualPeriod(e); ok && e.Kind == kind && name == schedule.Name && !end.Before(periodEnd) {
+			periodEnd = end.Add(schedule.Every)
+		}
+	}
+
+	var due []accrual
+	var pending Bitcoin
+	for ; !periodEnd.After(now); periodEnd = periodEnd.Add(schedule.Every) {
+		// the periods worked out so far in this call all ended before this one
+		balance, accrued := pending, pending
+		for _, e := range w.ledger {
+			name, end, isAccrual := accrualPeriod(e)
+			if !isAccrual {
+				end = e.Time
+			}
+			if !end.Before(periodEnd) {
+				continue
+			}
+			balance += e.Amount
+			if isAccrual && e.Kind == kind && name == schedule.Name {
+				accrued += e.Amount
+			}
+		}
+
+		principal := balance
+		if kind == KindInterest {
+			principal -= accrued
+		}
+
+		amount := schedule.Rule.Amount(balance, principal)
+		if kind == KindFee {
+			amount = -capFee(-amount, w.balance-w.held()+pending)
+		}
+		pending += amount
+		due = append(due, accrual{kind, amount, accrualMemo(schedule.Name, periodEnd)})
+	}
+
+	return due
+}
+
+// accrualTime must be called with w.mu held. Accruals are stamped with when they're
+// applied, never earlier than the last entry, so the ledger stays in time order
+// and its running balance holds even when catching up on periods long gone.
+func (w *Wallet) accrualTime(now time.Time) time.Time {
+	if n := len(w.ledger); n > 0 && w.ledger[n-1].Time.After(now) {
+		return w.ledger[n-1].Time
+	}
+	return now
+}
+
+const periodEndingSeparator = ", period ending "
+
+// accrualMemo records which schedule an entry is for and the end of the period it covers,
+// e.g. "savings, period ending 2024-03-02T09:00:00Z".
+func accrualMemo(name string, periodEnd time.Time) string {
+	return name + periodEndingSeparator + periodEnd.Format(time.RFC3339Nano)
+}
+
+// accrualPeriod is the reverse of accrualMemo. Ledgers written before the period went into
+// the memo have just the name there and are stamped with the end of the period instead.
+func accrualPeriod(e Entry) (name string, periodEnd time.Time, ok bool) {
+	if e.Kind != KindInterest && e.Kind != KindFee {
+		return "", time.Time{}, false
+	}
+
+	if i := strings.LastIndex(e.Memo, periodEndingSeparator); i >= 0 {
+		if end, err := time.Parse(time.RFC3339Nano, e.Memo[i+len(periodEndingSeparator):]); err == nil {
+			return e.Memo[:i], end, true
+		}
+	}
+	return e.Memo, e.Time, true
+}
+
+// Accruer is what a Scheduler runs schedules against: a *Wallet, or a wallet
+// in a FileSystemWalletStore, which you get from the store's Accruer method.
+type Accruer interface {
+	Accrue(schedule Schedule, now time.Time) ([]Entry, error)
+}
+
+// Scheduler runs schedules against wallets on a clock.
+type Scheduler struct {
+	mu    sync.Mutex
+	clock Clock
+	jobs  []scheduledJob
+}
+
+type scheduledJob struct {
+	target   Accruer
+	schedule Schedule
+}
+
+func NewScheduler(clock Clock) *Scheduler {
+	if clock == nil {
+		clock = ClockFunc(time.Now)
+	}
+	return &Scheduler{clock: clock}
+}
+
+func (s *Scheduler) Add(target Accruer, schedule Schedule) error {
+	if err := schedule.validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs = append(s.jobs, scheduledJob{target, schedule})
+	return nil
+}
+
+// RunDue applies everything that has come due, in the order the schedules were added.
+// Calling it again straight away does nothing, so it is safe to call as often as you like.
+func (s *Scheduler) RunDue() ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+
+	var recorded []Entry
+	for _, job := range s.jobs {
+		entries, err := job.target.Accrue(job.schedule, now)
+		if err != nil {
+			return recorded, err
+		}
+		recorded = append(recorded, entries...)
+	}
+	return recorded, nil
+}
+
+// Run calls RunDue straight away and then every interval until ctx is done.
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.RunDue(); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
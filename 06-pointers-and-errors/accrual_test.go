@@ -0,0 +1,248 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+const day = 24 * time.Hour
+
+func TestAccrualRules(t *testing.T) {
+	cases := []struct {
+		name               string
+		rule               AccrualRule
+		balance, principal Bitcoin
+		want               Bitcoin
+	}{
+		{"simple interest pays on the principal", SimpleInterest{BasisPoints: 500}, 1100, 1000, 50},
+		{"simple interest rounds down", SimpleInterest{BasisPoints: 150}, 99, 99, 1},
+		{"compound interest pays on the balance", CompoundInterest{BasisPoints: 500}, 1100, 1000, 55},
+		{"compound interest rounds down", CompoundInterest{BasisPoints: 199}, 100, 100, 1},
+		{"no interest on a negative balance", CompoundInterest{BasisPoints: 500}, -100, -100, 0},
+		{"fixed fee", FixedFee{Fee: 5}, 100, 100, -5},
+		{"fixed fee never goes below zero", FixedFee{Fee: 5}, 3, 3, -3},
+		{"fixed fee on an empty wallet", FixedFee{Fee: 5}, 0, 0, 0},
+		{"percentage fee rounds half up", PercentageFee{BasisPoints: 250}, 100, 100, -3},
+		{"percentage fee rounds less than half down", PercentageFee{BasisPoints: 240}, 100, 100, -2},
+		{"percentage fee on a negative balance", PercentageFee{BasisPoints: 250}, -100, -100, 0},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Amount(tt.balance, tt.principal); got != tt.want {
+				t.Errorf("got %s want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	t.Run("applies each period as it ends", func(t *testing.T) {
+		clock := &ManualClock{now: start}
+		wallet := NewWallet(clock)
+		wallet.Deposit(Bitcoin(1000))
+		scheduler := NewScheduler(clock)
+		scheduler.Add(wallet, Schedule{Name: "savings", Start: start, Every: day, Rule: CompoundInterest{BasisPoints: 100}})
+
+		entries, _ := scheduler.RunDue()
+		if len(entries) != 0 {
+			t.Fatalf("nothing is due yet, got %+v", entries)
+		}
+
+		clock.Advance(day)
+		entries, _ = scheduler.RunDue()
+		if len(entries) != 1 {
+			t.Fatalf("got %+v", entries)
+		}
+		want := Entry{ID: 2, Time: start.Add(day), Kind: KindInterest, Amount: 10, Balance: 1010, Memo: "savings, period ending 2024-03-02T09:00:00Z"}
+		if entries[0] != want {
+			t.Errorf("got %+v want %+v", entries[0], want)
+		}
+
+		entries, _ = scheduler.RunDue()
+		if len(entries) != 0 {
+			t.Errorf("running again should do nothing, got %+v", entries)
+		}
+	})
+
+	t.Run("catches up missed periods exactly once", func(t *testing.T) {
+		clock := &ManualClock{now: start}
+		wallet := NewWallet(clock)
+		wallet.Deposit(Bitcoin(1000))
+		schedule := Schedule{Name: "savings", Start: start, Every: day, Rule: CompoundInterest{BasisPoints: 100}}
+
+		clock.Advance(3*day + time.Hour)
+		// a fresh scheduler, as if we'd just restarted, still knows where to pick up from the ledger
+		for i := 0; i < 2; i++ {
+			scheduler := NewScheduler(clock)
+			scheduler.Add(wallet, schedule)
+			scheduler.RunDue()
+		}
+
+		interest := []Bitcoin{}
+		for _, e := range wallet.Ledger() {
+			if e.Kind == KindInterest {
+				interest = append(interest, e.Amount)
+			}
+		}
+		// 1% compounded: 1000 -> 1010 -> 1020 -> 1030 (10.20 and 10.30 round down)
+		if len(interest) != 3 || interest[0] != 10 || interest[1] != 10 || interest[2] != 10 {
+			t.Errorf("got %v", interest)
+		}
+		assertBalance(t, wallet, Bitcoin(1030))
+		assertBalance(t, wallet, Replay(wallet.Ledger()))
+	})
+
+	t.Run("catching up uses the balance as it was at each period", func(t *testing.T) {
+		clock := &ManualClock{now: start}
+		wallet := NewWallet(clock)
+		wallet.Deposit(Bitcoin(1000))
+		schedule := Schedule{Name: "savings", Start: start, Every: day, Rule: SimpleInterest{BasisPoints: 1000}}
+
+		clock.Advance(day + time.Hour)
+		wallet.Deposit(Bitcoin(1000)) // after the first period ended
+		clock.Advance(day)
+
+		entries, _ := wallet.Accrue(schedule, clock.Now())
+
+		// 10% of 1000 for the first day, then 10% of 2000 as interest isn't paid on interest
+		if len(entries) != 2 || entries[0].Amount != 100 || entries[1].Amount != 200 {
+			t.Errorf("got %+v", entries)
+		}
+	})
+
+	t.Run("catching up keeps the ledger in time order", func(t *testing.T) {
+		clock := &ManualClock{now: start}
+		wallet := NewWallet(clock)
+		wallet.Deposit(Bitcoin(1000))
+		schedule := Schedule{Name: "savings", Start: start, Every: day, Rule: SimpleInterest{BasisPoints: 1000}}
+
+		clock.Advance(day + time.Hour)
+		wallet.Withdraw(Bitcoin(500))
+		clock.Advance(day)
+		wallet.Accrue(schedule, clock.Now())
+
+		ledger := wallet.Ledger()
+		for i := 1; i < len(ledger); i++ {
+			if ledger[i].Time.Before(ledger[i-1].Time) {
+				t.Errorf("entry %d at %v is before entry %d at %v", ledger[i].ID, ledger[i].Time, ledger[i-1].ID, ledger[i-1].Time)
+			}
+		}
+		if last := ledger[len(ledger)-1]; last.Balance != wallet.Balance() {
+			t.Errorf("last entry says %s but the balance is %s", last.Balance, wallet.Balance())
+		}
+
+		// the withdrawal's statement doesn't change once the interest for before it is paid
+		statement := wallet.Statement(start, start.Add(day+2*time.Hour))
+		if statement.ClosingBalance != 500 {
+			t.Errorf("got closing balance %s want 500", statement.ClosingBalance)
+		}
+	})
+
+	t.Run("a late fee is capped at the balance the wallet has now", func(t *testing.T) {
+		clock := &ManualClock{now: start}
+		wallet := NewWallet(clock)
+		wallet.Deposit(Bitcoin(100))
+		schedule := Schedule{Name: "account fee", Start: start, Every: day, Rule: FixedFee{Fee: 10}}
+
+		clock.Advance(day + time.Hour)
+		wallet.Withdraw(Bitcoin(100)) // after the period ended, before we caught up
+
+		entries, _ := wallet.Accrue(schedule, clock.Now())
+
+		if len(entries) != 1 || entries[0].Amount != 0 {
+			t.Errorf("got %+v", entries)
+		}
+		assertBalance(t, wallet, Bitcoin(0))
+	})
+
+	t.Run("a fee doesn't take money that's on hold", func(t *testing.T) {
+		clock := &ManualClock{now: start}
+		wallet := NewWallet(clock)
+		wallet.Deposit(Bitcoin(100))
+		id, err := wallet.Hold(Bitcoin(100))
+		assertNoError(t, err)
+		schedule := Schedule{Name: "account fee", Start: start, Every: day, Rule: FixedFee{Fee: 10}}
+
+		clock.Advance(day)
+		entries, _ := wallet.Accrue(schedule, clock.Now())
+		if len(entries) != 1 || entries[0].Amount != 0 {
+			t.Errorf("got %+v", entries)
+		}
+
+		assertNoError(t, wallet.Capture(id, Bitcoin(100)))
+		assertBalance(t, wallet, Bitcoin(0))
+	})
+
+	t.Run("fees don't use up the customer's withdrawal limits", func(t *testing.T) {
+		clock := &ManualClock{now: start}
+		wallet := NewWallet(clock, DailyLimit{Limit: 50}, MonthlyLimit{Limit: 50})
+		wallet.Deposit(Bitcoin(1000))
+		schedule := Schedule{Name: "account fee", Start: start, Every: time.Hour, Rule: FixedFee{Fee: 10}}
+
+		clock.Advance(5 * time.Hour)
+		wallet.Accrue(schedule, clock.Now())
+		assertBalance(t, wallet, Bitcoin(950))
+
+		assertNoError(t, wallet.Withdraw(Bitcoin(50)))
+	})
+
+	t.Run("fees and interest on the same wallet", func(t *testing.T) {
+		clock := &ManualClock{now: start}
+		wallet := NewWallet(clock)
+		wallet.Deposit(Bitcoin(10))
+		scheduler := NewScheduler(clock)
+		scheduler.Add(wallet, Schedule{Name: "account fee", Start: start, Every: day, Rule: FixedFee{Fee: 4}})
+		scheduler.Add(wallet, Schedule{Name: "monthly fee", Start: start, Every: 30 * day, Rule: PercentageFee{BasisPoints: 500}})
+
+		clock.Advance(3 * day)
+		scheduler.RunDue()
+
+		// 10 -> 6 -> 2 -> 0, the fee never takes the wallet below zero
+		assertBalance(t, wallet, Bitcoin(0))
+		fees := 0
+		for _, e := range wallet.Ledger() {
+			if e.Kind == KindFee {
+				fees++
+				if name, _, _ := accrualPeriod(e); name != "account fee" {
+					t.Errorf("got %+v", e)
+				}
+			}
+		}
+		if fees != 3 {
+			t.Errorf("got %d fee entries want 3", fees)
+		}
+	})
+
+	t.Run("ledgers from before the period was in the memo carry on", func(t *testing.T) {
+		wallet := &Wallet{
+			balance: 1010,
+			ledger: []Entry{
+				{ID: 1, Time: start, Kind: KindDeposit, Amount: 1000, Balance: 1000},
+				{ID: 2, Time: start.Add(day), Kind: KindInterest, Amount: 10, Balance: 1010, Memo: "savings"},
+			},
+		}
+		schedule := Schedule{Name: "savings", Start: start, Every: day, Rule: CompoundInterest{BasisPoints: 100}}
+
+		entries, _ := wallet.Accrue(schedule, start.Add(2*day))
+
+		want := "savings, period ending 2024-03-03T09:00:00Z"
+		if len(entries) != 1 || entries[0].Memo != want || entries[0].Amount != 10 {
+			t.Errorf("got %+v want just the second period", entries)
+		}
+	})
+
+	t.Run("rejects schedules it can't run", func(t *testing.T) {
+		scheduler := NewScheduler(nil)
+		for _, schedule := range []Schedule{
+			{Start: start, Every: day, Rule: FixedFee{Fee: 1}},
+			{Name: "fee", Start: start, Every: 0, Rule: FixedFee{Fee: 1}},
+			{Name: "fee", Start: start, Every: day},
+		} {
+			if err := scheduler.Add(&Wallet{}, schedule); !errors.Is(err, ErrInvalidSchedule) {
+				t.Errorf("%+v: got %v want %v", schedule, err, ErrInvalidSchedule)
+			}
+		}
+	})
+}
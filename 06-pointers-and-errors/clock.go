@@ -0,0 +1,16 @@
+package main
+
+import "time"
+
+// Just like the Sleeper in the mocking chapter, reaching for time.Now directly makes
+// anything time based hard to test, so we inject a Clock instead.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc lets an ordinary function be used as a Clock, e.g. ClockFunc(time.Now).
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// A hold reserves money in a wallet without taking it out yet, like a card payment
+// that's been authorised but not charged. Held money still counts towards Balance
+// but can't be spent by anything else, so AvailableBalance goes down straight away.
+// Later the hold is either captured (the money really leaves) or voided (it's released).
+// If nobody does either before it expires, it's released on its own.
+
+type HoldID uint64
+
+// Hold records the amount reserved, when it was placed and when it lapses.
+type Hold struct {
+	ID      HoldID    `json:"id"`
+	Amount  Bitcoin   `json:"amount"`
+	Placed  time.Time `json:"placed"`
+	Expires time.Time `json:"expires"`
+}
+
+const DefaultHoldTimeout = 7 * 24 * time.Hour
+
+const KindCapture = EntryKind("capture")
+
+var (
+	ErrHoldNotFound       = errors.New("hold not found, it may have expired")
+	ErrCaptureExceedsHold = errors.New("cannot capture more than was held")
+	ErrInvalidHoldTimeout = errors.New("hold timeout must be more than zero")
+)
+
+func (w *Wallet) Hold(amount Bitcoin) (HoldID, error) {
+	return w.HoldFor(amount, DefaultHoldTimeout)
+}
+
+// HoldFor reserves amount until timeout has passed on the wallet's clock.
+// Placing a hold goes through the same checks as a withdrawal, since that's where the money is heading,
+// and until it's captured or released it counts towards the daily and monthly limits like one.
+func (w *Wallet) HoldFor(amount Bitcoin, timeout time.Duration) (HoldID, error) {
+	if timeout <= 0 {
+		return 0, ErrInvalidHoldTimeout
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+	w.expireHolds(now)
+
+	if err := w.checkWithdrawal(amount, now); err != nil {
+		return 0, err
+	}
+
+	if w.holds == nil {
+		w.holds = map[HoldID]Hold{}
+	}
+	w.lastHoldID++
+	hold := Hold{ID: w.lastHoldID, Amount: amount, Placed: now, Expires: now.Add(timeout)}
+	w.holds[hold.ID] = hold
+
+	return hold.ID, nil
+}
+
+// Capture takes up to the held amount out of the wallet and releases the hold,
+// so capturing less than was held gives the rest back.
+func (w *Wallet) Capture(id HoldID, amount Bitcoin) error {
+	if err := checkPositive(amount); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.unlock()
+
+	now := w.now()
+	w.expireHolds(now)
+
+	hold, ok := w.holds[id]
+	if !ok {
+		return fmt.Errorf("%w: %d", ErrHoldNotFound, id)
+	}
+	if amount > hold.Amount {
+		return fmt.Errorf("%w: held %s, capturing %s", ErrCaptureExceedsHold, hold.Amount, amount)
+	}
+
+	// the money was checked when the hold was placed, so there's nothing more to check here
+	delete(w.holds, id)
+	w.balance -= amount
+	w.record(now, KindCapture, -amount, fmt.Sprintf("hold %d", id))
+
+	return nil
+}
+
+// Void releases a hold without taking any money.
+func (w *Wallet) Void(id HoldID) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.expireHolds(w.now())
+
+	if _, ok := w.holds[id]; !ok {
+		return fmt.Errorf("%w: %d", ErrHoldNotFound, id)
+	}
+	delete(w.holds, id)
+
+	return nil
+}
+
+// Holds lists the holds that are still active, oldest first.
+func (w *Wallet) Holds() []Hold {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.expireHolds(w.now())
+
+	holds := make([]Hold, 0, len(w.holds))
+	for _, hold := range w.holds {
+		holds = append(holds, hold)
+	}
+	sort.Slice(holds, func(i, j int) bool { return holds[i].ID < holds[j].ID })
+	return holds
+}
+
+// AvailableBalance is the balance minus everything on hold, which is what can actually be spent.
+func (w *Wallet) AvailableBalance() Bitcoin {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.expireHolds(w.now())
+	return w.balance - w.held()
+}
+
+// expireHolds must be called with w.mu held.
+// Holds are released lazily whenever the wallet looks at them, so there's no background goroutine
+// to manage and a test's clock decides exactly when a hold lapses.
+func (w *Wallet) expireHolds(now time.Time) {
+	for id, hold := range w.holds {
+		if !now.Before(hold.Expires) {
+			delete(w.holds, id)
+		}
+	}
+}
+
+// held must be called with w.mu held.
+func (w *Wallet) held() Bitcoin {
+	var total Bitcoin
+	for _, hold := range w.holds {
+		total += hold.Amount
+	}
+	return total
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHolds(t *testing.T) {
+	t.Run("a hold reduces the available balance but not the balance", func(t *testing.T) {
+		wallet := &Wallet{}
+		wallet.Deposit(Bitcoin(100))
+
+		_, err := wallet.Hold(Bitcoin(30))
+
+		assertNoError(t, err)
+		assertBalance(t, wallet, Bitcoin(100))
+		assertAvailable(t, wallet, Bitcoin(70))
+	})
+
+	t.Run("held money can't be withdrawn or held again", func(t *testing.T) {
+		wallet := &Wallet{}
+		wallet.Deposit(Bitcoin(100))
+		wallet.Hold(Bitcoin(80))
+
+		assertError(t, wallet.Withdraw(Bitcoin(30)), ErrInsufficientFunds)
+		assertError(t, Transfer(wallet, &Wallet{}, Bitcoin(30)), ErrInsufficientFunds)
+		_, err := wallet.Hold(Bitcoin(30))
+		assertError(t, err, ErrInsufficientFunds)
+
+		assertNoError(t, wallet.Withdraw(Bitcoin(20)))
+		assertAvailable(t, wallet, Bitcoin(0))
+	})
+
+	t.Run("capture takes the money and releases the rest", func(t *testing.T) {
+		wallet := NewWallet(&StubClock{now: start})
+		wallet.Deposit(Bitcoin(100))
+		id, _ := wallet.Hold(Bitcoin(30))
+
+		err := wallet.Capture(id, Bitcoin(25))
+
+		assertNoError(t, err)
+		assertBalance(t, wallet, Bitcoin(75))
+		assertAvailable(t, wallet, Bitcoin(75))
+		if len(wallet.Holds()) != 0 {
+			t.Errorf("hold should be gone, got %v", wallet.Holds())
+		}
+
+		entry := wallet.Ledger()[1]
+		if entry.Kind != KindCapture || entry.Amount != -25 || entry.Balance != 75 {
+			t.Errorf("got %+v", entry)
+		}
+		assertBalance(t, wallet, Replay(wallet.Ledger()))
+	})
+
+	t.Run("can't capture more than was held, or the same hold twice", func(t *testing.T) {
+		wallet := &Wallet{}
+		wallet.Deposit(Bitcoin(100))
+		id, _ := wallet.Hold(Bitcoin(30))
+
+		if err := wallet.Capture(id, Bitcoin(31)); !errors.Is(err, ErrCaptureExceedsHold) {
+			t.Errorf("got %v want %v", err, ErrCaptureExceedsHold)
+		}
+		assertNoError(t, wallet.Capture(id, Bitcoin(30)))
+		if err := wallet.Capture(id, Bitcoin(30)); !errors.Is(err, ErrHoldNotFound) {
+			t.Errorf("got %v want %v", err, ErrHoldNotFound)
+		}
+		assertBalance(t, wallet, Bitcoin(70))
+	})
+
+	t.Run("void gives the money back", func(t *testing.T) {
+		wallet := &Wallet{}
+		wallet.Deposit(Bitcoin(100))
+		id, _ := wallet.Hold(Bitcoin(30))
+
+		assertNoError(t, wallet.Void(id))
+
+		assertAvailable(t, wallet, Bitcoin(100))
+		if err := wallet.Void(id); !errors.Is(err, ErrHoldNotFound) {
+			t.Errorf("got %v want %v", err, ErrHoldNotFound)
+		}
+		if err := wallet.Capture(id, Bitcoin(1)); !errors.Is(err, ErrHoldNotFound) {
+			t.Errorf("got %v want %v", err, ErrHoldNotFound)
+		}
+	})
+
+	t.Run("holds count towards the daily and monthly limits", func(t *testing.T) {
+		clock := &ManualClock{now: time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC)}
+		wallet := NewWallet(clock, DailyLimit{Limit: 100}, MonthlyLimit{Limit: 150})
+		wallet.Deposit(Bitcoin(1000))
+		refused := func(err, want error) {
+			t.Helper()
+			if !errors.Is(err, want) {
+				t.Errorf("got %v want %v", err, want)
+			}
+		}
+
+		id, err := wallet.Hold(Bitcoin(100))
+		assertNoError(t, err)
+
+		_, err = wallet.Hold(Bitcoin(100))
+		refused(err, ErrDailyLimitExceeded)
+		refused(wallet.Withdraw(Bitcoin(1)), ErrDailyLimitExceeded)
+
+		// capturing moves the money from the hold to the ledger, so it still counts once
+		assertNoError(t, wallet.Capture(id, Bitcoin(100)))
+		refused(wallet.Withdraw(Bitcoin(1)), ErrDailyLimitExceeded)
+
+		clock.Advance(24 * time.Hour)
+		id, err = wallet.Hold(Bitcoin(50))
+		assertNoError(t, err)
+		_, err = wallet.Hold(Bitcoin(1))
+		refused(err, ErrMonthlyLimitExceeded)
+
+		// a voided hold never left, so it stops counting
+		assertNoError(t, wallet.Void(id))
+		assertNoError(t, wallet.Withdraw(Bitcoin(50)))
+		assertBalance(t, wallet, Bitcoin(850))
+	})
+
+	t.Run("holds expire after their timeout", func(t *testing.T) {
+		clock := &ManualClock{now: start}
+		wallet := NewWallet(clock)
+		wallet.Deposit(Bitcoin(100))
+		short, _ := wallet.HoldFor(Bitcoin(30), time.Hour)
+		long, _ := wallet.Hold(Bitcoin(20))
+
+		clock.Advance(59 * time.Minute)
+		assertAvailable(t, wallet, Bitcoin(50))
+
+		clock.Advance(time.Minute)
+		assertAvailable(t, wallet, Bitcoin(80))
+		if err := wallet.Capture(short, Bitcoin(30)); !errors.Is(err, ErrHoldNotFound) {
+			t.Errorf("got %v want %v", err, ErrHoldNotFound)
+		}
+
+		clock.Advance(DefaultHoldTimeout)
+		assertAvailable(t, wallet, Bitcoin(100))
+		if err := wallet.Void(long); !errors.Is(err, ErrHoldNotFound) {
+			t.Errorf("got %v want %v", err, ErrHoldNotFound)
+		}
+	})
+
+	t.Run("rejects bad amounts and timeouts", func(t *testing.T) {
+		wallet := &Wallet{}
+		wallet.Deposit(Bitcoin(100))
+
+		if _, err := wallet.Hold(Bitcoin(0)); !errors.Is(err, ErrNonPositiveAmount) {
+			t.Errorf("got %v want %v", err, ErrNonPositiveAmount)
+		}
+		if _, err := wallet.HoldFor(Bitcoin(10), 0); !errors.Is(err, ErrInvalidHoldTimeout) {
+			t.Errorf("got %v want %v", err, ErrInvalidHoldTimeout)
+		}
+	})
+
+	t.Run("holds, withdrawals and deposits race safely", func(t *testing.T) {
+		wallet := &Wallet{}
+		wallet.Deposit(Bitcoin(500))
+
+		var mu sync.Mutex
+		var captured, withdrawn Bitcoin
+
+		var wg sync.WaitGroup
+		for i := 0; i < 200; i++ {
+			wg.Add(3)
+			go func() {
+				defer wg.Done()
+				id, err := wallet.Hold(Bitcoin(3))
+				if err != nil {
+					return
+				}
+				if i%2 == 0 {
+					wallet.Void(id)
+					return
+				}
+				if wallet.Capture(id, Bitcoin(2)) == nil {
+					mu.Lock()
+					captured += 2
+					mu.Unlock()
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				if wallet.Withdraw(Bitcoin(4)) == nil {
+					mu.Lock()
+					withdrawn += 4
+					mu.Unlock()
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				wallet.Deposit(Bitcoin(1))
+				if available := wallet.AvailableBalance(); available < 0 {
+					t.Errorf("available balance went negative: %s", available)
+				}
+			}()
+		}
+		wg.Wait()
+
+		assertBalance(t, wallet, Bitcoin(700)-captured-withdrawn)
+		assertAvailable(t, wallet, wallet.Balance())
+		assertBalance(t, wallet, Replay(wallet.Ledger()))
+	})
+}
+
+func assertAvailable(t testing.TB, wallet *Wallet, want Bitcoin) {
+	t.Helper()
+	if got := wallet.AvailableBalance(); got != want {
+		t.Errorf("got available %s want %s", got, want)
+	}
+}
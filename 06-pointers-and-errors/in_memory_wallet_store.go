@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// WalletStore is what the HTTP server needs from wherever the wallets live,
+// the same way PlayerServer only needs a PlayerStore.
+type WalletStore interface {
+	Create(name string) error
+	Deposit(name string, amount Bitcoin, memo string) error
+	Withdraw(name string, amount Bitcoin, memo string) error
+	Transfer(from, to string, amount Bitcoin, memo string) error
+	Balance(name string) (Bitcoin, error)
+	Ledger(name string) ([]Entry, error)
+	Names() []string
+}
+
+// InMemoryWalletStore forgets everything on restart, use FileSystemWalletStore when that matters.
+type InMemoryWalletStore struct {
+	mu       sync.RWMutex
+	wallets  map[string]*Wallet
+	clock    Clock
+	policies []Policy
+}
+
+func NewInMemoryWalletStore(clock Clock, policies ...Policy) *InMemoryWalletStore {
+	return &InMemoryWalletStore{wallets: map[string]*Wallet{}, clock: clock, policies: policies}
+}
+
+func (i *InMemoryWalletStore) Create(name string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if _, ok := i.wallets[name]; ok {
+		return fmt.Errorf("%w: %q", ErrWalletExists, name)
+	}
+	i.wallets[name] = NewWallet(i.clock, i.policies...)
+	return nil
+}
+
+func (i *InMemoryWalletStore) wallet(name string) (*Wallet, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	wallet, ok := i.wallets[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrWalletNotFound, name)
+	}
+	return wallet, nil
+}
+
+// Each Wallet does its own locking, so once we've found it we can let go of the store's lock.
+
+func (i *InMemoryWalletStore) Deposit(name string, amount Bitcoin, memo string) error {
+	wallet, err := i.wallet(name)
+	if err != nil {
+		return err
+	}
+	return wallet.DepositWithMemo(amount, memo)
+}
+
+func (i *InMemoryWalletStore) Withdraw(name string, amount Bitcoin, memo string) error {
+	wallet, err := i.wallet(name)
+	if err != nil {
+		return err
+	}
+	return wallet.WithdrawWithMemo(amount, memo)
+}
+
+func (i *InMemoryWalletStore) Transfer(from, to string, amount Bitcoin, memo string) error {
+	source, err := i.wallet(from)
+	if err != nil {
+		return err
+	}
+	destination, err := i.wallet(to)
+	if err != nil {
+		return err
+	}
+	return TransferWithMemo(source, destination, amount, memo)
+}
+
+func (i *InMemoryWalletStore) Balance(name string) (Bitcoin, error) {
+	wallet, err := i.wallet(name)
+	if err != nil {
+		return 0, err
+	}
+	return wallet.Balance(), nil
+}
+
+func (i *InMemoryWalletStore) Ledger(name string) ([]Entry, error) {
+	wallet, err := i.wallet(name)
+	if err != nil {
+		return nil, err
+	}
+	return wallet.Ledger(), nil
+}
+
+func (i *InMemoryWalletStore) Names() []string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	names := make([]string, 0, len(i.wallets))
+	for name := range i.wallets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Every change to a Wallet's balance is recorded as an Entry in its ledger.
+// Entries are values and the ledger only ever hands out copies, so once written they can't be changed.
+type Entry struct {
+	ID   uint64    `json:"id"`
+	Time time.Time `json:"time"`
+	Kind EntryKind `json:"kind"`
+	// Amount is signed: money coming in is positive and money going out is negative,
+	// so replaying a ledger is just adding the amounts up.
+	Amount  Bitcoin `json:"amount"`
+	Balance Bitcoin `json:"balance"`
+	Memo    string  `json:"memo,omitempty"`
+}
+
+type EntryKind string
+
+const (
+	KindDeposit    = EntryKind("deposit")
+	KindWithdrawal = EntryKind("withdrawal")
+)
+
+// record must be called with w.mu held, right after the balance has changed,
+// and the lock released with unlock so the entry reaches subscribers.
+func (w *Wallet) record(at time.Time, kind EntryKind, amount Bitcoin, memo string) Entry {
+	entry := Entry{
+		ID:      uint64(len(w.ledger) + 1),
+		Time:    at,
+		Kind:    kind,
+		Amount:  amount,
+		Balance: w.balance,
+		Memo:    memo,
+	}
+	w.ledger = append(w.ledger, entry)
+	w.pending = append(w.pending, entry)
+	return entry
+}
+
+func (w *Wallet) now() time.Time {
+	if w.clock == nil {
+		return time.Now()
+	}
+	return w.clock.Now()
+}
+
+// Ledger returns a copy of every entry, oldest first.
+func (w *Wallet) Ledger() []Entry {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]Entry(nil), w.ledger...)
+}
+
+// History returns the entries recorded in [from, to).
+func (w *Wallet) History(from, to time.Time) []Entry {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.history(from, to)
+}
+
+func (w *Wallet) history(from, to time.Time) []Entry {
+	var entries []Entry
+	for _, e := range w.ledger {
+		if !e.Time.Before(from) && e.Time.Before(to) {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Replay works out the balance from nothing but the entries.
+// For a whole ledger it must always agree with Wallet.Balance.
+func Replay(entries []Entry) Bitcoin {
+	var balance Bitcoin
+	for _, e := range entries {
+		balance += e.Amount
+	}
+	return balance
+}
+
+// A Statement is the slice of a ledger between two points in time,
+// along with the balance going in and coming out.
+type Statement struct {
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	OpeningBalance Bitcoin   `json:"openingBalance"`
+	ClosingBalance Bitcoin   `json:"closingBalance"`
+	Entries        []Entry   `json:"entries"`
+}
+
+func (w *Wallet) Statement(from, to time.Time) Statement {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	statement := Statement{From: from, To: to, Entries: w.history(from, to)}
+	if statement.Entries == nil {
+		statement.Entries = []Entry{}
+	}
+
+	// everything before the statement starts adds up to the opening balance
+	for _, e := range w.ledger {
+		if e.Time.Before(from) {
+			statement.OpeningBalance += e.Amount
+		}
+	}
+	statement.ClosingBalance = statement.OpeningBalance + Replay(statement.Entries)
+
+	return statement
+}
+
+func (s Statement) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// WriteCSV writes one row per entry under a header row, amounts are whole units.
+func (s Statement) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	writer.Write([]string{"id", "time", "kind", "amount", "balance", "memo"})
+	for _, e := range s.Entries {
+		writer.Write([]string{
+			strconv.FormatUint(e.ID, 10),
+			e.Time.Format(time.RFC3339Nano),
+			string(e.Kind),
+			strconv.Itoa(int(e.Amount)),
+			strconv.Itoa(int(e.Balance)),
+			e.Memo,
+		})
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// StubClock starts at a fixed time and moves on a minute every time it is asked,
+// so every entry gets a different, predictable timestamp.
+type StubClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (s *StubClock) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now
+	s.now = s.now.Add(time.Minute)
+	return now
+}
+
+var start = time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC)
+
+func TestLedger(t *testing.T) {
+	t.Run("records every deposit and withdrawal", func(t *testing.T) {
+		wallet := NewWallet(&StubClock{now: start})
+
+		wallet.DepositWithMemo(Bitcoin(20), "salary")
+		wallet.Withdraw(Bitcoin(5))
+		wallet.Withdraw(Bitcoin(100)) // fails, so it shouldn't be recorded
+
+		want := []Entry{
+			{ID: 1, Time: start, Kind: KindDeposit, Amount: 20, Balance: 20, Memo: "salary"},
+			{ID: 2, Time: start.Add(time.Minute), Kind: KindWithdrawal, Amount: -5, Balance: 15},
+		}
+		assertEntries(t, wallet.Ledger(), want)
+	})
+
+	t.Run("handing out the ledger doesn't let you change it", func(t *testing.T) {
+		wallet := NewWallet(&StubClock{now: start})
+		wallet.Deposit(Bitcoin(10))
+
+		entries := wallet.Ledger()
+		entries[0].Amount = 1000
+
+		if got := wallet.Ledger()[0].Amount; got != 10 {
+			t.Errorf("ledger was changed from outside, amount is now %s", got)
+		}
+	})
+
+	t.Run("history only returns entries in the range", func(t *testing.T) {
+		wallet := NewWallet(&StubClock{now: start})
+		for i := 1; i <= 5; i++ {
+			wallet.Deposit(Bitcoin(i))
+		}
+
+		got := wallet.History(start.Add(time.Minute), start.Add(3*time.Minute))
+
+		if len(got) != 2 || got[0].ID != 2 || got[1].ID != 3 {
+			t.Errorf("got %v", got)
+		}
+	})
+
+	t.Run("balance always equals the ledger replay", func(t *testing.T) {
+		wallet := &Wallet{}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				wallet.Deposit(Bitcoin(3))
+			}()
+			go func() {
+				defer wg.Done()
+				wallet.Withdraw(Bitcoin(2))
+			}()
+		}
+		wg.Wait()
+
+		entries := wallet.Ledger()
+		assertBalance(t, wallet, Replay(entries))
+		for i, e := range entries {
+			if Replay(entries[:i+1]) != e.Balance {
+				t.Fatalf("entry %d says balance %s but replay gives %s", e.ID, e.Balance, Replay(entries[:i+1]))
+			}
+		}
+	})
+}
+
+func TestStatement(t *testing.T) {
+	wallet := NewWallet(&StubClock{now: start})
+	wallet.Deposit(Bitcoin(50))
+	wallet.WithdrawWithMemo(Bitcoin(20), "rent")
+	wallet.DepositWithMemo(Bitcoin(5), "refund, partial")
+	wallet.Withdraw(Bitcoin(1))
+
+	statement := wallet.Statement(start.Add(time.Minute), start.Add(3*time.Minute))
+
+	t.Run("has opening and closing balances", func(t *testing.T) {
+		if statement.OpeningBalance != 50 || statement.ClosingBalance != 35 || len(statement.Entries) != 2 {
+			t.Errorf("got %+v", statement)
+		}
+	})
+
+	t.Run("exports to CSV", func(t *testing.T) {
+		buffer := bytes.Buffer{}
+		if err := statement.WriteCSV(&buffer); err != nil {
+			t.Fatal(err)
+		}
+
+		want := "id,time,kind,amount,balance,memo\n" +
+			"2,2024-03-01T09:01:00Z,withdrawal,-20,30,rent\n" +
+			"3,2024-03-01T09:02:00Z,deposit,5,35,\"refund, partial\"\n"
+		if buffer.String() != want {
+			t.Errorf("got\n%s\nwant\n%s", buffer.String(), want)
+		}
+	})
+
+	t.Run("exports to JSON", func(t *testing.T) {
+		buffer := bytes.Buffer{}
+		if err := statement.WriteJSON(&buffer); err != nil {
+			t.Fatal(err)
+		}
+
+		var got Statement
+		if err := json.Unmarshal(buffer.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, statement) {
+			t.Errorf("got %+v want %+v", got, statement)
+		}
+	})
+}
+
+func assertEntries(t testing.TB, got, want []Entry) {
+	t.Helper()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v want %+v", got, want)
+	}
+}
@@ -2,7 +2,12 @@ package main
 
 import (
 	"errors"
+	"flag"
 	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
 )
 
 type Bitcoin int
@@ -28,9 +33,35 @@ func (b Bitcoin) String() string {
 
 // Remember we can access the internal balance field in the struct using the "receiver" variable.
 
+// Like the Counter in the sync chapter, a Wallet is shared between goroutines,
+// so every read and write of balance goes through mu.
+// A Withdraw has to check the balance and subtract from it in one step,
+// otherwise two goroutines can both see enough money and overdraw the wallet.
+// Because of the mutex a Wallet must not be copied after first use, pass *Wallet around instead.
 type Wallet struct {
+	mu sync.Mutex
 	// Go lets you create new types from existing ones
 	balance Bitcoin
+	// ledger is the audit trail of every Deposit and Withdraw, see ledger.go
+	ledger []Entry
+	clock  Clock
+	// id decides the order locks are taken in by Transfer, see transfer.go
+	id atomic.Uint64
+	// policies are checked on every withdrawal, see policy.go
+	policies []Policy
+	// holds reserve money that hasn't left yet, see hold.go
+	holds      map[HoldID]Hold
+	lastHoldID HoldID
+	// subscribers hear about every balance change, see subscribe.go
+	subscribers []*Subscription
+	pending     []Entry
+	publishing  sync.Mutex
+}
+
+// NewWallet lets you choose the clock that stamps ledger entries and the policies withdrawals must pass.
+// The zero value Wallet is ready to use too, it uses the real time and has no policies.
+func NewWallet(clock Clock, policies ...Policy) *Wallet {
+	return &Wallet{clock: clock, policies: policies}
 }
 
 // (!) In Go, when you call a function or a method the arguments are copied.
@@ -62,11 +93,25 @@ type Wallet struct {
 
 // The difference is the receiver type is *Wallet rather than Wallet which you can read as "a pointer to a wallet".
 
-func (w *Wallet) Deposit(amount Bitcoin) {
-	fmt.Printf("address of balance in Deposit is %p \n", &w.balance)
+// Depositing a negative amount would be a withdrawal that skips every check, so Deposit can fail too.
+func (w *Wallet) Deposit(amount Bitcoin) error {
+	return w.DepositWithMemo(amount, "")
+}
+
+func (w *Wallet) DepositWithMemo(amount Bitcoin, memo string) error {
+	if err := checkPositive(amount); err != nil {
+		return err
+	}
+
+	// fmt.Printf("address of balance in Deposit is %p \n", &w.balance)
 	// BEFORE using a pointer * : address of balance in Deposit is 0xc00000a340
 	// AFTER using a pointer * : address of balance in Deposit is 0xc00000a338
+	w.mu.Lock()
+	defer w.unlock()
+
 	w.balance += amount
+	w.record(w.now(), KindDeposit, amount, memo)
+	return nil
 }
 
 func (w *Wallet) Balance() Bitcoin {
@@ -77,6 +122,9 @@ func (w *Wallet) Balance() Bitcoin {
 	// The makers of Go deemed this notation cumbersome, so the language permits us to write w.balance, without an explicit dereference
 	// These pointers to structs even have their own name: struct pointers and they are automatically dereferenced
 
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	return w.balance
 }
 
@@ -89,14 +137,49 @@ var ErrInsufficientFunds = errors.New("cannot withdraw, insufficient funds")
 // In Go, if you want to indicate an error it is idiomatic for your function to return an err for the caller to check and act on.
 
 func (w *Wallet) Withdraw(amount Bitcoin) error {
+	return w.WithdrawWithMemo(amount, "")
+}
+
+func (w *Wallet) WithdrawWithMemo(amount Bitcoin, memo string) error {
+	w.mu.Lock()
+	defer w.unlock()
+
+	// if amount > w.balance {
+	// 	// errors.New creates a new error with a message of your choosing
+	// 	return ErrInsufficientFunds
+	// }
 
-	if amount > w.balance {
-		// errors.New creates a new error with a message of your choosing
-		return ErrInsufficientFunds
+	// the balance check now lives in checkWithdrawal along with the withdrawal policies
+	now := w.now()
+	if err := w.checkWithdrawal(amount, now); err != nil {
+		return err
 	}
 
 	w.balance -= amount
+	w.record(now, KindWithdrawal, -amount, memo)
 
 	// we have to return a nil error if successful
 	return nil
 }
+
+// ----------
+
+// main serves the wallets over HTTP, see server.go.
+// Pass -dir to keep them on disk between restarts, otherwise they only live in memory.
+func main() {
+	addr := flag.String("addr", ":5000", "address to listen on")
+	dir := flag.String("dir", "", "directory to keep the wallets in")
+	flag.Parse()
+
+	var store WalletStore = NewInMemoryWalletStore(nil)
+	if *dir != "" {
+		fileStore, err := NewFileSystemWalletStore(*dir, nil)
+		if err != nil {
+			log.Fatalf("problem opening wallet store: %v", err)
+		}
+		// no need to Close on the way out, every change is already fsynced
+		store = fileStore
+	}
+
+	log.Fatal(http.ListenAndServe(*addr, NewWalletServer(store, nil)))
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"sync"
 	"testing"
 )
 
@@ -9,31 +10,94 @@ func TestWallet(t *testing.T) {
 	t.Run("deposit", func(t *testing.T) {
 		wallet := Wallet{}
 		wallet.Deposit(Bitcoin(10))
-		assertBalance(t, wallet, Bitcoin(10))
+		assertBalance(t, &wallet, Bitcoin(10))
 	})
 
 	t.Run("withdraw", func(t *testing.T) {
-		wallet := Wallet{balance: Bitcoin(20)}
+		// the ledger has to explain every coin, so we fund the wallet with a deposit rather than setting balance
+		wallet := Wallet{}
+		wallet.Deposit(Bitcoin(20))
 		// we need to add an err check here
 		err := wallet.Withdraw(Bitcoin(10))
 
 		assertNoError(t, err)
-		assertBalance(t, wallet, Bitcoin(10))
+		assertBalance(t, &wallet, Bitcoin(10))
 	})
 
 	t.Run("withdraw insufficient funds", func(t *testing.T) {
-		wallet := Wallet{balance: Bitcoin(20)}
+		wallet := Wallet{}
+		wallet.Deposit(Bitcoin(20))
 
 		err := wallet.Withdraw(Bitcoin(100))
 
 		assertError(t, err, ErrInsufficientFunds)
-		assertBalance(t, wallet, Bitcoin(20))
+		assertBalance(t, &wallet, Bitcoin(20))
+	})
+}
+
+func TestWalletConcurrency(t *testing.T) {
+	// run with go test -race to have the race detector check this too
+	t.Run("balance never goes negative under contention", func(t *testing.T) {
+		const workers = 50
+		const rounds = 200
+
+		wallet := &Wallet{}
+		var withdrawn [workers]Bitcoin
+
+		var wg sync.WaitGroup
+		wg.Add(workers * 2)
+		for i := 0; i < workers; i++ {
+			go func() {
+				defer wg.Done()
+				for j := 0; j < rounds; j++ {
+					wallet.Deposit(1)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for j := 0; j < rounds; j++ {
+					if wallet.Withdraw(2) == nil {
+						withdrawn[i] += 2
+					}
+				}
+			}()
+		}
+
+		// keep checking the balance while the deposits and withdrawals are still running
+		done := make(chan struct{})
+		var observer sync.WaitGroup
+		observer.Add(1)
+		go func() {
+			defer observer.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					if b := wallet.Balance(); b < 0 {
+						t.Errorf("balance went negative: %s", b)
+						return
+					}
+				}
+			}
+		}()
+
+		wg.Wait()
+		close(done)
+		observer.Wait()
+
+		var totalWithdrawn Bitcoin
+		for _, w := range withdrawn {
+			totalWithdrawn += w
+		}
+		assertBalance(t, wallet, Bitcoin(workers*rounds)-totalWithdrawn)
 	})
 }
 
 // we can move the test helpers out of the test scope and into their own functions
 
-func assertBalance(t testing.TB, wallet Wallet, want Bitcoin) {
+// Wallet holds a mutex now, so like assertCounter in the sync chapter we take a pointer rather than copying it
+func assertBalance(t testing.TB, wallet *Wallet, want Bitcoin) {
 	t.Helper()
 	got := wallet.Balance()
 	if got != want {
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// Bitcoin can only count whole coins. Money counts in the smallest unit a currency has
+// (cents, satoshis, ...) as an int64, so there's never any floating point rounding,
+// and carries its currency code with it so we can't add dollars to euros by accident.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// Currency describes how many minor units make up one major unit, as a power of ten.
+// USD has 2 (100 cents to the dollar), BTC has 8 (100,000,000 satoshis to the coin) and JPY has none.
+type Currency struct {
+	Code     string
+	Exponent int
+}
+
+var currencies = map[string]Currency{
+	"BTC": {"BTC", 8},
+	"EUR": {"EUR", 2},
+	"GBP": {"GBP", 2},
+	"JPY": {"JPY", 0},
+	"USD": {"USD", 2},
+}
+
+var (
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrCurrencyMismatch = errors.New("currencies do not match")
+	ErrOverflow         = errors.New("amount is too large")
+)
+
+func LookupCurrency(code string) (Currency, error) {
+	currency, ok := currencies[strings.ToUpper(code)]
+	if !ok {
+		return Currency{}, fmt.Errorf("%w %q", ErrUnknownCurrency, code)
+	}
+	return currency, nil
+}
+
+const satoshisPerBitcoin = 100_000_000
+
+// Money returns the amount in satoshis. Bitcoin can hold far more whole coins than
+// an int64 can hold satoshis, so it returns ErrOverflow rather than a wrapped-around amount.
+func (b Bitcoin) Money() (Money, error) {
+	if b > math.MaxInt64/satoshisPerBitcoin || b < math.MinInt64/satoshisPerBitcoin {
+		return Money{}, fmt.Errorf("%w: %s in satoshis", ErrOverflow, b)
+	}
+	return Money{Amount: int64(b) * satoshisPerBitcoin, Currency: "BTC"}, nil
+}
+
+// String puts the decimal point where the currency says it goes, e.g. "12.34 USD" or "0.00000001 BTC".
+func (m Money) String() string {
+	currency, err := LookupCurrency(m.Currency)
+	if err != nil {
+		return fmt.Sprintf("%d %s", m.Amount, m.Currency)
+	}
+
+	sign := ""
+	// work with the absolute value as a uint64 so math.MinInt64 doesn't overflow
+	units := uint64(m.Amount)
+	if m.Amount < 0 {
+		sign = "-"
+		units = -units
+	}
+
+	if currency.Exponent == 0 {
+		return fmt.Sprintf("%s%d %s", sign, units, currency.Code)
+	}
+
+	scale := uint64(math.Pow10(currency.Exponent))
+	return fmt.Sprintf("%s%d.%0*d %s", sign, units/scale, currency.Exponent, units%scale, currency.Code)
+}
+
+// ParseMoney is the reverse of String. It won't accept more decimal places than the currency has,
+// because "0.001 USD" can't be held without rounding.
+func ParseMoney(s string) (Money, error) {
+	amount, code, ok := strings.Cut(strings.TrimSpace(s), " ")
+	if !ok {
+		return Money{}, fmt.Errorf("%w %q, want e.g. \"12.34 USD\"", ErrInvalidAmount, s)
+	}
+
+	currency, err := LookupCurrency(strings.TrimSpace(code))
+	if err != nil {
+		return Money{}, err
+	}
+
+	minor, err := parseMinorUnits(amount, currency.Exponent)
+	if err != nil {
+		return Money{}, err
+	}
+
+	return Money{Amount: minor, Currency: currency.Code}, nil
+}
+
+func parseMinorUnits(amount string, exponent int) (int64, error) {
+	invalid := fmt.Errorf("%w %q", ErrInvalidAmount, amount)
+
+	negative := strings.HasPrefix(amount, "-")
+	amount = strings.TrimPrefix(amount, "-")
+
+	whole, fraction, hasPoint := strings.Cut(amount, ".")
+	if whole == "" || (hasPoint && fraction == "") || len(fraction) > exponent {
+		return 0, invalid
+	}
+	// pad the fraction out so "1.5 USD" is read as 150 cents
+	digits := whole + fraction + strings.Repeat("0", exponent-len(fraction))
+
+	// like String, work with the magnitude as a uint64, as a negative amount
+	// can go one further than a positive one and math.MinInt64 has to round trip
+	limit := uint64(math.MaxInt64)
+	if negative {
+		limit++
+	}
+
+	var magnitude uint64
+	for _, d := range digits {
+		if d < '0' || d > '9' {
+			return 0, invalid
+		}
+		if magnitude > (limit-uint64(d-'0'))/10 {
+			return 0, fmt.Errorf("%w: %q", ErrOverflow, amount)
+		}
+		magnitude = magnitude*10 + uint64(d-'0')
+	}
+
+	if negative {
+		return int64(-magnitude), nil
+	}
+	return int64(magnitude), nil
+}
+
+// Add is exact, so instead of quietly wrapping around it tells you when the result won't fit.
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	sum := m.Amount + other.Amount
+	if (other.Amount > 0 && sum < m.Amount) || (other.Amount < 0 && sum > m.Amount) {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+// ExchangeRateProvider is how a wallet finds out what one currency is worth in another.
+// The rate is how many major units of to you get for one major unit of from,
+// as an exact fraction so converting doesn't pick up floating point errors.
+type ExchangeRateProvider interface {
+	Rate(from, to string) (*big.Rat, error)
+}
+
+var ErrNoRate = errors.New("no exchange rate")
+
+// FixedRates is an ExchangeRateProvider with a table you fill in yourself, handy for tests.
+type FixedRates struct {
+	rates map[[2]string]*big.Rat
+}
+
+func NewFixedRates() *FixedRates {
+	return &FixedRates{rates: map[[2]string]*big.Rat{}}
+}
+
+// Set takes the rate as a decimal string such as "1.0842", which big.Rat reads exactly.
+// If the reverse rate hasn't been set it is filled in as the inverse.
+func (f *FixedRates) Set(from, to, rate string) error {
+	r, ok := new(big.Rat).SetString(rate)
+	if !ok || r.Sign() <= 0 {
+		return fmt.Errorf("%w: rate %q", ErrInvalidAmount, rate)
+	}
+
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	f.rates[[2]string{from, to}] = r
+	if _, ok := f.rates[[2]string{to, from}]; !ok {
+		f.rates[[2]string{to, from}] = new(big.Rat).Inv(r)
+	}
+	return nil
+}
+
+func (f *FixedRates) Rate(from, to string) (*big.Rat, error) {
+	if from == to {
+		return big.NewRat(1, 1), nil
+	}
+	r, ok := f.rates[[2]string{from, to}]
+	if !ok {
+		return nil, fmt.Errorf("%w from %s to %s", ErrNoRate, from, to)
+	}
+	return new(big.Rat).Set(r), nil
+}
+
+// Convert works in minor units: 150 cents at 0.9 EUR per USD is 135 euro cents.
+// Anything smaller than the target's minor unit is rounded half away from zero.
+func Convert(m Money, to string, rates ExchangeRateProvider) (Money, error) {
+	source, err := LookupCurrency(m.Currency)
+	if err != nil {
+		return Money{}, err
+	}
+	target, err := LookupCurrency(to)
+	if err != nil {
+		return Money{}, err
+	}
+
+	rate, err := rates.Rate(source.Code, target.Code)
+	if err != nil {
+		return Money{}, err
+	}
+
+	// minor units in the target = minor units in the source * rate * 10^(target exponent - source exponent)
+	value := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), rate)
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(target.Exponent-source.Exponent))), nil))
+	if target.Exponent > source.Exponent {
+		value.Mul(value, scale)
+	} else {
+		value.Quo(value, scale)
+	}
+
+	rounded := roundHalfAwayFromZero(value)
+	if !rounded.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: rounded.Int64(), Currency: target.Code}, nil
+}
+
+func roundHalfAwayFromZero(r *big.Rat) *big.Int {
+	half := big.NewRat(1, 2)
+	if r.Sign() < 0 {
+		half.Neg(half)
+	}
+	shifted := new(big.Rat).Add(r, half)
+	// Quo truncates towards zero, which after adding the half gives us our rounding
+	return new(big.Int).Quo(shifted.Num(), shifted.Denom())
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
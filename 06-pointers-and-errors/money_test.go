@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestMoneyString(t *testing.T) {
+	cases := []struct {
+		money Money
+		want  string
+	}{
+		{Money{1234, "USD"}, "12.34 USD"},
+		{Money{5, "EUR"}, "0.05 EUR"},
+		{Money{-150, "GBP"}, "-1.50 GBP"},
+		{Money{1, "BTC"}, "0.00000001 BTC"},
+		{Money{500, "JPY"}, "500 JPY"},
+		{Money{math.MinInt64, "JPY"}, "-9223372036854775808 JPY"},
+		{Money{7, "XYZ"}, "7 XYZ"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.money.String(); got != tt.want {
+				t.Errorf("got %q want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBitcoinMoney(t *testing.T) {
+	got, err := Bitcoin(3).Money()
+	assertNoError(t, err)
+	if got.String() != "3.00000000 BTC" {
+		t.Errorf("got %s", got)
+	}
+
+	for _, b := range []Bitcoin{math.MaxInt64/satoshisPerBitcoin + 1, math.MinInt64/satoshisPerBitcoin - 1, math.MaxInt64} {
+		if _, err := b.Money(); !errors.Is(err, ErrOverflow) {
+			t.Errorf("%d: got %v want %v", b, err, ErrOverflow)
+		}
+	}
+
+	if got, _ := Bitcoin(math.MaxInt64 / satoshisPerBitcoin).Money(); got.Amount != math.MaxInt64/satoshisPerBitcoin*satoshisPerBitcoin {
+		t.Errorf("the largest amount that fits came out as %s", got)
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	t.Run("valid amounts", func(t *testing.T) {
+		cases := []struct {
+			input string
+			want  Money
+		}{
+			{"12.34 USD", Money{1234, "USD"}},
+			{"1.5 usd", Money{150, "USD"}},
+			{"7 EUR", Money{700, "EUR"}},
+			{"-0.01 GBP", Money{-1, "GBP"}},
+			{"0.00000001 BTC", Money{1, "BTC"}},
+			{"500 JPY", Money{500, "JPY"}},
+			{"9223372036854775807 JPY", Money{math.MaxInt64, "JPY"}},
+			{"-9223372036854775808 JPY", Money{math.MinInt64, "JPY"}},
+			{"-92233720368.54775808 BTC", Money{math.MinInt64, "BTC"}},
+		}
+
+		for _, tt := range cases {
+			got, err := ParseMoney(tt.input)
+			if err != nil {
+				t.Errorf("%q: didn't want an error, got %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("%q: got %#v want %#v", tt.input, got, tt.want)
+			}
+			// whatever we parse should format back the same way
+			if again, _ := ParseMoney(got.String()); again != got {
+				t.Errorf("%q: round trip gave %#v", tt.input, again)
+			}
+		}
+	})
+
+	t.Run("invalid amounts", func(t *testing.T) {
+		cases := []struct {
+			input string
+			want  error
+		}{
+			{"12.34", ErrInvalidAmount},
+			{"12.345 USD", ErrInvalidAmount},
+			{"1.5 JPY", ErrInvalidAmount},
+			{"1. USD", ErrInvalidAmount},
+			{".5 USD", ErrInvalidAmount},
+			{"1,000 USD", ErrInvalidAmount},
+			{"12 XYZ", ErrUnknownCurrency},
+			{"99999999999999999999 JPY", ErrOverflow},
+			{"9223372036854775808 JPY", ErrOverflow},
+			{"-9223372036854775809 JPY", ErrOverflow},
+		}
+
+		for _, tt := range cases {
+			_, err := ParseMoney(tt.input)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("%q: got error %v want %v", tt.input, err, tt.want)
+			}
+		}
+	})
+}
+
+func TestMoneyAdd(t *testing.T) {
+	got, err := Money{150, "USD"}.Add(Money{25, "USD"})
+	assertNoError(t, err)
+	if got != (Money{175, "USD"}) {
+		t.Errorf("got %s", got)
+	}
+
+	if _, err := (Money{150, "USD"}).Add(Money{25, "EUR"}); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("got %v want %v", err, ErrCurrencyMismatch)
+	}
+	if _, err := (Money{math.MaxInt64, "USD"}).Add(Money{1, "USD"}); !errors.Is(err, ErrOverflow) {
+		t.Errorf("got %v want %v", err, ErrOverflow)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	rates := NewFixedRates()
+	assertNoError(t, rates.Set("USD", "EUR", "0.9"))
+	assertNoError(t, rates.Set("USD", "JPY", "150.25"))
+	assertNoError(t, rates.Set("BTC", "USD", "60000"))
+
+	cases := []struct {
+		from Money
+		to   string
+		want Money
+	}{
+		{Money{150, "USD"}, "EUR", Money{135, "EUR"}},
+		{Money{135, "EUR"}, "USD", Money{150, "USD"}},
+		{Money{100, "USD"}, "JPY", Money{150, "JPY"}},   // 150.25 rounds down
+		{Money{1, "USD"}, "JPY", Money{2, "JPY"}},       // 1.5025 rounds up
+		{Money{1, "EUR"}, "USD", Money{1, "USD"}},       // 1.11 cents rounds down
+		{Money{10, "BTC"}, "USD", Money{1, "USD"}},      // 0.6 cents rounds up
+		{Money{100, "USD"}, "BTC", Money{1667, "BTC"}},  // 1666.67 satoshis
+		{Money{-150, "USD"}, "EUR", Money{-135, "EUR"}}, // negatives round away from zero too
+		{Money{42, "USD"}, "USD", Money{42, "USD"}},
+	}
+
+	for _, tt := range cases {
+		got, err := Convert(tt.from, tt.to, rates)
+		assertNoError(t, err)
+		if got != tt.want {
+			t.Errorf("%s to %s: got %s want %s", tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	if _, err := Convert(Money{100, "GBP"}, "JPY", rates); !errors.Is(err, ErrNoRate) {
+		t.Errorf("got %v want %v", err, ErrNoRate)
+	}
+	if err := rates.Set("USD", "GBP", "-1"); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("got %v want %v", err, ErrInvalidAmount)
+	}
+}
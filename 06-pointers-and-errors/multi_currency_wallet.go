@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// MultiCurrencyWallet keeps a separate balance for every currency it holds
+// and can move money between them through an ExchangeRateProvider.
+type MultiCurrencyWallet struct {
+	mu       sync.Mutex
+	balances map[string]int64
+	rates    ExchangeRateProvider
+}
+
+func NewMultiCurrencyWallet(rates ExchangeRateProvider) *MultiCurrencyWallet {
+	return &MultiCurrencyWallet{balances: map[string]int64{}, rates: rates}
+}
+
+func (w *MultiCurrencyWallet) Deposit(amount Money) error {
+	currency, err := checkAmount(amount)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.credit(Money{Amount: amount.Amount, Currency: currency.Code})
+}
+
+func (w *MultiCurrencyWallet) Withdraw(amount Money) error {
+	currency, err := checkAmount(amount)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if amount.Amount > w.balances[currency.Code] {
+		return ErrInsufficientFunds
+	}
+	w.balances[currency.Code] -= amount.Amount
+	return nil
+}
+
+// Balance of a currency the wallet has never held is zero in that currency.
+func (w *MultiCurrencyWallet) Balance(currency string) (Money, error) {
+	c, err := LookupCurrency(currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return Money{Amount: w.balances[c.Code], Currency: c.Code}, nil
+}
+
+// Balances lists every non-zero balance, sorted by currency code.
+func (w *MultiCurrencyWallet) Balances() []Money {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var balances []Money
+	for code, amount := range w.balances {
+		if amount != 0 {
+			balances = append(balances, Money{Amount: amount, Currency: code})
+		}
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Currency < balances[j].Currency })
+	return balances
+}
+
+// Convert takes amount out of its currency's balance and puts its value in the to currency,
+// returning what was credited. Either both sides happen or neither does.
+func (w *MultiCurrencyWallet) Convert(amount Money, to string) (Money, error) {
+	currency, err := checkAmount(amount)
+	if err != nil {
+		return Money{}, err
+	}
+	amount.Currency = currency.Code
+
+	// ask for the rate before taking the lock, a real provider might be slow
+	converted, err := Convert(amount, to, w.rates)
+	if err != nil {
+		return Money{}, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if amount.Amount > w.balances[currency.Code] {
+		return Money{}, ErrInsufficientFunds
+	}
+	w.balances[currency.Code] -= amount.Amount
+	if err := w.credit(converted); err != nil {
+		w.balances[currency.Code] += amount.Amount
+		return Money{}, err
+	}
+	return converted, nil
+}
+
+// credit must be called with w.mu held.
+func (w *MultiCurrencyWallet) credit(amount Money) error {
+	balance, err := Money{Amount: w.balances[amount.Currency], Currency: amount.Currency}.Add(amount)
+	if err != nil {
+		return err
+	}
+	w.balances[amount.Currency] = balance.Amount
+	return nil
+}
+
+func checkAmount(amount Money) (Currency, error) {
+	currency, err := LookupCurrency(amount.Currency)
+	if err != nil {
+		return Currency{}, err
+	}
+	if amount.Amount <= 0 {
+		return Currency{}, fmt.Errorf("%w: %s must be more than zero", ErrInvalidAmount, amount)
+	}
+	return currency, nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestMultiCurrencyWallet(t *testing.T) {
+	newWallet := func(t testing.TB) *MultiCurrencyWallet {
+		t.Helper()
+		rates := NewFixedRates()
+		assertNoError(t, rates.Set("USD", "EUR", "0.9"))
+		return NewMultiCurrencyWallet(rates)
+	}
+
+	t.Run("keeps a balance per currency", func(t *testing.T) {
+		wallet := newWallet(t)
+
+		assertNoError(t, wallet.Deposit(Money{1000, "USD"}))
+		assertNoError(t, wallet.Deposit(Money{500, "eur"}))
+		assertNoError(t, wallet.Withdraw(Money{250, "USD"}))
+
+		assertMoneyBalance(t, wallet, Money{750, "USD"})
+		assertMoneyBalance(t, wallet, Money{500, "EUR"})
+		assertMoneyBalance(t, wallet, Money{0, "GBP"})
+
+		want := []Money{{500, "EUR"}, {750, "USD"}}
+		if got := wallet.Balances(); !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v want %v", got, want)
+		}
+	})
+
+	t.Run("can't withdraw more than the balance of that currency", func(t *testing.T) {
+		wallet := newWallet(t)
+		wallet.Deposit(Money{1000, "USD"})
+
+		err := wallet.Withdraw(Money{1, "EUR"})
+
+		assertError(t, err, ErrInsufficientFunds)
+	})
+
+	t.Run("rejects unknown currencies and amounts that aren't positive", func(t *testing.T) {
+		wallet := newWallet(t)
+
+		if err := wallet.Deposit(Money{100, "XYZ"}); !errors.Is(err, ErrUnknownCurrency) {
+			t.Errorf("got %v want %v", err, ErrUnknownCurrency)
+		}
+		if err := wallet.Deposit(Money{-100, "USD"}); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("got %v want %v", err, ErrInvalidAmount)
+		}
+		if err := wallet.Withdraw(Money{0, "USD"}); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("got %v want %v", err, ErrInvalidAmount)
+		}
+	})
+
+	t.Run("converts between currencies", func(t *testing.T) {
+		wallet := newWallet(t)
+		wallet.Deposit(Money{1000, "USD"})
+
+		got, err := wallet.Convert(Money{150, "USD"}, "EUR")
+
+		assertNoError(t, err)
+		if got != (Money{135, "EUR"}) {
+			t.Errorf("got %s", got)
+		}
+		assertMoneyBalance(t, wallet, Money{850, "USD"})
+		assertMoneyBalance(t, wallet, Money{135, "EUR"})
+	})
+
+	t.Run("a failed conversion changes nothing", func(t *testing.T) {
+		wallet := newWallet(t)
+		wallet.Deposit(Money{1000, "USD"})
+
+		_, err := wallet.Convert(Money{2000, "USD"}, "EUR")
+		assertError(t, err, ErrInsufficientFunds)
+
+		_, err = wallet.Convert(Money{100, "USD"}, "JPY")
+		if !errors.Is(err, ErrNoRate) {
+			t.Errorf("got %v want %v", err, ErrNoRate)
+		}
+
+		assertMoneyBalance(t, wallet, Money{1000, "USD"})
+		assertMoneyBalance(t, wallet, Money{0, "EUR"})
+	})
+
+	t.Run("is safe for concurrent use", func(t *testing.T) {
+		wallet := newWallet(t)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				wallet.Deposit(Money{100, "USD"})
+			}()
+			go func() {
+				defer wg.Done()
+				wallet.Deposit(Money{100, "EUR"})
+			}()
+		}
+		wg.Wait()
+
+		assertMoneyBalance(t, wallet, Money{10000, "USD"})
+		assertMoneyBalance(t, wallet, Money{10000, "EUR"})
+	})
+}
+
+func assertMoneyBalance(t testing.TB, wallet *MultiCurrencyWallet, want Money) {
+	t.Helper()
+	got, err := wallet.Balance(want.Currency)
+	assertNoError(t, err)
+	if got != want {
+		t.Errorf("got %s want %s", got, want)
+	}
+}
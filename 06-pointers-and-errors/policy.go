@@ -0,0 +1,262 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// A Policy is a rule every withdrawal (and the sending side of every transfer) has to pass.
+// Check returns nil to let the withdrawal through, or an error saying why it can't happen.
+type Policy interface {
+	Check(withdrawal Withdrawal) error
+}
+
+// Withdrawal is everything a Policy gets to look at.
+type Withdrawal struct {
+	Amount Bitcoin
+	// Balance is the balance before the withdrawal and Held is how much of it is reserved by holds
+	Balance Bitcoin
+	Held    Bitcoin
+	Time    time.Time
+
+	ledger []Entry
+	holds  []Hold
+}
+
+// Available is the part of the balance that isn't on hold, the rules about
+// how low a wallet can go are all about this rather than Balance.
+func (w Withdrawal) Available() Bitcoin {
+	return w.Balance - w.Held
+}
+
+// WithdrawnSince adds up the money that has left the wallet from since onwards.
+// Fees and interest are the bank's doing rather than the customer's, so they don't count.
+func (w Withdrawal) WithdrawnSince(since time.Time) Bitcoin {
+	var total Bitcoin
+	for _, e := range w.ledger {
+		if e.Kind == KindFee || e.Kind == KindInterest {
+			continue
+		}
+		if e.Amount < 0 && !e.Time.Before(since) {
+			total -= e.Amount
+		}
+	}
+	return total
+}
+
+// HeldSince adds up the active holds placed from since onwards. That money hasn't left yet
+// but it's on its way, so a limit that ignored it could be dodged by holding and then capturing.
+func (w Withdrawal) HeldSince(since time.Time) Bitcoin {
+	var total Bitcoin
+	for _, hold := range w.holds {
+		if !hold.Placed.Before(since) {
+			total += hold.Amount
+		}
+	}
+	return total
+}
+
+// checkWithdrawal must be called with w.mu held.
+func (w *Wallet) checkWithdrawal(amount Bitcoin, now time.Time) error {
+	if err := checkPositive(amount); err != nil {
+		return err
+	}
+
+	w.expireHolds(now)
+	withdrawal := Withdrawal{Amount: amount, Balance: w.balance, Held: w.held(), Time: now, ledger: w.ledger}
+	for _, hold := range w.holds {
+		withdrawal.holds = append(withdrawal.holds, hold)
+	}
+
+	// unless an OverdraftPolicy says otherwise the available balance can't go below zero
+	if !w.allowsOverdraft() && amount > withdrawal.Available() {
+		return ErrInsufficientFunds
+	}
+
+	for _, policy := range w.policies {
+		if err := policy.Check(withdrawal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Wallet) allowsOverdraft() bool {
+	for _, policy := range w.policies {
+		if _, ok := policy.(OverdraftPolicy); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Like ErrInsufficientFunds, these sentinel errors let callers ask errors.Is(err, ErrDailyLimitExceeded).
+// Each policy's error type matches its sentinel and carries the details, which you can get at with errors.As.
+var (
+	ErrNonPositiveAmount      = errors.New("amount must be more than zero")
+	ErrOverdraftLimitExceeded = errors.New("cannot withdraw, overdraft limit exceeded")
+	ErrDailyLimitExceeded     = errors.New("cannot withdraw, daily limit exceeded")
+	ErrMonthlyLimitExceeded   = errors.New("cannot withdraw, monthly limit exceeded")
+	ErrBelowMinimumBalance    = errors.New("cannot withdraw, balance would fall below the minimum")
+	ErrTransactionTooLarge    = errors.New("cannot withdraw, amount is over the single transaction maximum")
+)
+
+func checkPositive(amount Bitcoin) error {
+	if amount <= 0 {
+		return fmt.Errorf("%w, got %s", ErrNonPositiveAmount, amount)
+	}
+	return nil
+}
+
+// OverdraftPolicy is a Policy that lets the available balance go below zero. Without one on
+// the wallet, a withdrawal can never take more than is available, whatever the other policies say.
+// Asking for the capability rather than the Overdraft type means &Overdraft{} works too,
+// as does any overdraft rule of your own.
+type OverdraftPolicy interface {
+	Policy
+	OverdraftLimit() Bitcoin
+}
+
+// Overdraft lets the balance go as far as Limit below zero.
+type Overdraft struct {
+	Limit Bitcoin
+}
+
+func (o Overdraft) OverdraftLimit() Bitcoin {
+	return o.Limit
+}
+
+type OverdraftError struct {
+	Limit     Bitcoin
+	Available Bitcoin
+	Amount    Bitcoin
+}
+
+func (e *OverdraftError) Error() string {
+	return fmt.Sprintf("%v: withdrawing %s from %s available goes past the %s overdraft", ErrOverdraftLimitExceeded, e.Amount, e.Available, e.Limit)
+}
+
+// Going past the overdraft is still a case of not having enough money,
+// so code that only knows about ErrInsufficientFunds keeps working.
+func (e *OverdraftError) Is(target error) bool {
+	return target == ErrOverdraftLimitExceeded || target == ErrInsufficientFunds
+}
+
+func (o Overdraft) Check(w Withdrawal) error {
+	if w.Available()-w.Amount < -o.Limit {
+		return &OverdraftError{Limit: o.Limit, Available: w.Available(), Amount: w.Amount}
+	}
+	return nil
+}
+
+// DailyLimit caps how much can leave the wallet in one calendar day, in the clock's time zone.
+type DailyLimit struct {
+	Limit Bitcoin
+}
+
+// Withdrawn includes money on hold, see HeldSince.
+type DailyLimitError struct {
+	Limit     Bitcoin
+	Withdrawn Bitcoin
+	Amount    Bitcoin
+}
+
+func (e *DailyLimitError) Error() string {
+	return fmt.Sprintf("%v: %s already withdrawn today, %s more goes over %s", ErrDailyLimitExceeded, e.Withdrawn, e.Amount, e.Limit)
+}
+
+func (e *DailyLimitError) Is(target error) bool {
+	return target == ErrDailyLimitExceeded
+}
+
+func (d DailyLimit) Check(w Withdrawal) error {
+	y, m, day := w.Time.Date()
+	midnight := time.Date(y, m, day, 0, 0, 0, 0, w.Time.Location())
+	withdrawn := w.WithdrawnSince(midnight) + w.HeldSince(midnight)
+	if withdrawn+w.Amount > d.Limit {
+		return &DailyLimitError{Limit: d.Limit, Withdrawn: withdrawn, Amount: w.Amount}
+	}
+	return nil
+}
+
+// MonthlyLimit caps how much can leave the wallet in one calendar month, in the clock's time zone.
+type MonthlyLimit struct {
+	Limit Bitcoin
+}
+
+// Withdrawn includes money on hold, like DailyLimitError.
+type MonthlyLimitError struct {
+	Limit     Bitcoin
+	Withdrawn Bitcoin
+	Amount    Bitcoin
+}
+
+func (e *MonthlyLimitError) Error() string {
+	return fmt.Sprintf("%v: %s already withdrawn this month, %s more goes over %s", ErrMonthlyLimitExceeded, e.Withdrawn, e.Amount, e.Limit)
+}
+
+func (e *MonthlyLimitError) Is(target error) bool {
+	return target == ErrMonthlyLimitExceeded
+}
+
+func (m MonthlyLimit) Check(w Withdrawal) error {
+	y, month, _ := w.Time.Date()
+	first := time.Date(y, month, 1, 0, 0, 0, 0, w.Time.Location())
+	withdrawn := w.WithdrawnSince(first) + w.HeldSince(first)
+	if withdrawn+w.Amount > m.Limit {
+		return &MonthlyLimitError{Limit: m.Limit, Withdrawn: withdrawn, Amount: w.Amount}
+	}
+	return nil
+}
+
+// MinimumBalance keeps at least Minimum available in the wallet after every withdrawal.
+type MinimumBalance struct {
+	Minimum Bitcoin
+}
+
+type MinimumBalanceError struct {
+	Minimum   Bitcoin
+	Available Bitcoin
+	Amount    Bitcoin
+}
+
+func (e *MinimumBalanceError) Error() string {
+	return fmt.Sprintf("%v: withdrawing %s from %s available leaves less than %s", ErrBelowMinimumBalance, e.Amount, e.Available, e.Minimum)
+}
+
+func (e *MinimumBalanceError) Is(target error) bool {
+	return target == ErrBelowMinimumBalance
+}
+
+func (m MinimumBalance) Check(w Withdrawal) error {
+	if w.Available()-w.Amount < m.Minimum {
+		return &MinimumBalanceError{Minimum: m.Minimum, Available: w.Available(), Amount: w.Amount}
+	}
+	return nil
+}
+
+// MaxTransaction stops any single withdrawal bigger than Max.
+type MaxTransaction struct {
+	Max Bitcoin
+}
+
+type MaxTransactionError struct {
+	Max    Bitcoin
+	Amount Bitcoin
+}
+
+func (e *MaxTransactionError) Error() string {
+	return fmt.Sprintf("%v: %s is more than %s", ErrTransactionTooLarge, e.Amount, e.Max)
+}
+
+func (e *MaxTransactionError) Is(target error) bool {
+	return target == ErrTransactionTooLarge
+}
+
+func (m MaxTransaction) Check(w Withdrawal) error {
+	if w.Amount > m.Max {
+		return &MaxTransactionError{Max: m.Max, Amount: w.Amount}
+	}
+	return nil
+}
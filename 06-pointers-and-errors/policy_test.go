@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ManualClock only moves when a test tells it to.
+type ManualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (m *ManualClock) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+func (m *ManualClock) Advance(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = m.now.Add(d)
+}
+
+func TestNonPositiveAmounts(t *testing.T) {
+	for _, amount := range []Bitcoin{0, -5} {
+		wallet := &Wallet{}
+		wallet.Deposit(Bitcoin(10))
+
+		if err := wallet.Deposit(amount); !errors.Is(err, ErrNonPositiveAmount) {
+			t.Errorf("deposit %s: got %v want %v", amount, err, ErrNonPositiveAmount)
+		}
+		if err := wallet.Withdraw(amount); !errors.Is(err, ErrNonPositiveAmount) {
+			t.Errorf("withdraw %s: got %v want %v", amount, err, ErrNonPositiveAmount)
+		}
+		if err := Transfer(wallet, &Wallet{}, amount); !errors.Is(err, ErrNonPositiveAmount) {
+			t.Errorf("transfer %s: got %v want %v", amount, err, ErrNonPositiveAmount)
+		}
+		assertBalance(t, wallet, Bitcoin(10))
+	}
+}
+
+func TestOverdraft(t *testing.T) {
+	wallet := NewWallet(nil, Overdraft{Limit: 50})
+	wallet.Deposit(Bitcoin(20))
+
+	assertNoError(t, wallet.Withdraw(Bitcoin(60)))
+	assertBalance(t, wallet, Bitcoin(-40))
+
+	err := wallet.Withdraw(Bitcoin(11))
+
+	var overdraftErr *OverdraftError
+	if !errors.As(err, &overdraftErr) {
+		t.Fatalf("got %v want an *OverdraftError", err)
+	}
+	if overdraftErr.Limit != 50 || overdraftErr.Available != -40 || overdraftErr.Amount != 11 {
+		t.Errorf("got %+v", overdraftErr)
+	}
+	if !errors.Is(err, ErrOverdraftLimitExceeded) || !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("%v should be both %v and %v", err, ErrOverdraftLimitExceeded, ErrInsufficientFunds)
+	}
+	assertBalance(t, wallet, Bitcoin(-40))
+}
+
+func TestOverdraftByPointer(t *testing.T) {
+	wallet := NewWallet(nil, &Overdraft{Limit: 50})
+	wallet.Deposit(Bitcoin(20))
+
+	assertNoError(t, wallet.Withdraw(Bitcoin(60)))
+	assertBalance(t, wallet, Bitcoin(-40))
+
+	if err := wallet.Withdraw(Bitcoin(11)); !errors.Is(err, ErrOverdraftLimitExceeded) {
+		t.Errorf("got %v want %v", err, ErrOverdraftLimitExceeded)
+	}
+}
+
+func TestDailyLimit(t *testing.T) {
+	clock := &ManualClock{now: time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC)}
+	wallet := NewWallet(clock, DailyLimit{Limit: 100})
+	wallet.Deposit(Bitcoin(1000))
+
+	assertNoError(t, wallet.Withdraw(Bitcoin(60)))
+	clock.Advance(10 * time.Hour)
+	assertNoError(t, Transfer(wallet, &Wallet{}, Bitcoin(30)))
+
+	err := wallet.Withdraw(Bitcoin(11))
+
+	var limitErr *DailyLimitError
+	if !errors.As(err, &limitErr) || !errors.Is(err, ErrDailyLimitExceeded) {
+		t.Fatalf("got %v want a *DailyLimitError", err)
+	}
+	if limitErr.Withdrawn != 90 || limitErr.Amount != 11 {
+		t.Errorf("got %+v", limitErr)
+	}
+
+	// just after midnight the limit starts again
+	clock.Advance(5 * time.Hour)
+	assertNoError(t, wallet.Withdraw(Bitcoin(100)))
+	assertBalance(t, wallet, Bitcoin(810))
+}
+
+func TestMonthlyLimit(t *testing.T) {
+	clock := &ManualClock{now: time.Date(2024, time.January, 30, 12, 0, 0, 0, time.UTC)}
+	wallet := NewWallet(clock, MonthlyLimit{Limit: 100}, DailyLimit{Limit: 60})
+	wallet.Deposit(Bitcoin(1000))
+
+	assertNoError(t, wallet.Withdraw(Bitcoin(60)))
+	clock.Advance(24 * time.Hour)
+	assertNoError(t, wallet.Withdraw(Bitcoin(40)))
+
+	err := wallet.Withdraw(Bitcoin(1))
+
+	var limitErr *MonthlyLimitError
+	if !errors.As(err, &limitErr) || !errors.Is(err, ErrMonthlyLimitExceeded) {
+		t.Fatalf("got %v want a *MonthlyLimitError", err)
+	}
+	if errors.Is(err, ErrDailyLimitExceeded) {
+		t.Error("a monthly limit error shouldn't look like a daily one")
+	}
+
+	clock.Advance(24 * time.Hour) // February 1st
+	assertNoError(t, wallet.Withdraw(Bitcoin(1)))
+}
+
+func TestMinimumBalance(t *testing.T) {
+	wallet := NewWallet(nil, MinimumBalance{Minimum: 10})
+	wallet.Deposit(Bitcoin(30))
+
+	assertNoError(t, wallet.Withdraw(Bitcoin(20)))
+	err := wallet.Withdraw(Bitcoin(1))
+
+	var minimumErr *MinimumBalanceError
+	if !errors.As(err, &minimumErr) || !errors.Is(err, ErrBelowMinimumBalance) {
+		t.Fatalf("got %v want a *MinimumBalanceError", err)
+	}
+	if minimumErr.Minimum != 10 || minimumErr.Available != 10 {
+		t.Errorf("got %+v", minimumErr)
+	}
+	assertBalance(t, wallet, Bitcoin(10))
+}
+
+func TestMaxTransaction(t *testing.T) {
+	wallet := NewWallet(nil, MaxTransaction{Max: 25})
+	wallet.Deposit(Bitcoin(100))
+
+	assertNoError(t, wallet.Withdraw(Bitcoin(25)))
+	err := Transfer(wallet, &Wallet{}, Bitcoin(26))
+
+	var maxErr *MaxTransactionError
+	if !errors.As(err, &maxErr) || !errors.Is(err, ErrTransactionTooLarge) {
+		t.Fatalf("got %v want a *MaxTransactionError", err)
+	}
+	if maxErr.Max != 25 || maxErr.Amount != 26 {
+		t.Errorf("got %+v", maxErr)
+	}
+	if errors.Is(err, ErrInsufficientFunds) {
+		t.Error("a transaction over the maximum isn't a lack of funds")
+	}
+	assertBalance(t, wallet, Bitcoin(75))
+}
+
+func TestPoliciesWithoutOverdraftStillNeedFunds(t *testing.T) {
+	wallet := NewWallet(nil, MaxTransaction{Max: 1000})
+	wallet.Deposit(Bitcoin(10))
+
+	err := wallet.Withdraw(Bitcoin(20))
+
+	assertError(t, err, ErrInsufficientFunds)
+}
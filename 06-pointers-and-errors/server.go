@@ -0,0 +1,358 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WalletServer puts a WalletStore on the web:
+//
+//	POST /wallets                  {"name": "alice"}
+//	GET  /wallets/{name}
+//	POST /wallets/{name}/deposit   {"amount": 10, "memo": "salary"}
+//	POST /wallets/{name}/withdraw  {"amount": 5}
+//	POST /transfers                {"from": "alice", "to": "bob", "amount": 5}
+//
+// Errors come back as application/problem+json (RFC 9457).
+type WalletServer struct {
+	store       WalletStore
+	idempotency *idempotencyCache
+	http.Handler
+}
+
+const (
+	jsonContentType    = "application/json"
+	problemContentType = "application/problem+json"
+	maxBodySize        = 1 << 20
+)
+
+func NewWalletServer(store WalletStore, clock Clock) *WalletServer {
+	s := new(WalletServer)
+	s.store = store
+	s.idempotency = newIdempotencyCache(clock, DefaultIdempotencyTTL)
+
+	router := http.NewServeMux()
+	router.Handle("POST /wallets", http.HandlerFunc(s.createHandler))
+	router.Handle("GET /wallets/{name}", http.HandlerFunc(s.balanceHandler))
+	router.Handle("POST /wallets/{name}/deposit", http.HandlerFunc(s.depositHandler))
+	router.Handle("POST /wallets/{name}/withdraw", http.HandlerFunc(s.withdrawHandler))
+	router.Handle("POST /transfers", http.HandlerFunc(s.transferHandler))
+
+	// only requests that change something need protecting from retries
+	s.Handler = s.idempotency.middleware(router)
+	return s
+}
+
+type walletResponse struct {
+	Name    string  `json:"name"`
+	Balance Bitcoin `json:"balance"`
+}
+
+type amountRequest struct {
+	Amount Bitcoin `json:"amount"`
+	Memo   string  `json:"memo,omitempty"`
+}
+
+type transferRequest struct {
+	From   string  `json:"from"`
+	To     string  `json:"to"`
+	Amount Bitcoin `json:"amount"`
+	Memo   string  `json:"memo,omitempty"`
+}
+
+type transferResponse struct {
+	From walletResponse `json:"from"`
+	To   walletResponse `json:"to"`
+}
+
+func (s *WalletServer) createHandler(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Name string `json:"name"`
+	}
+	if !decodeRequest(w, r, &request) {
+		return
+	}
+	if request.Name == "" {
+		writeProblem(w, http.StatusBadRequest, "Invalid request", "name is required")
+		return
+	}
+
+	if err := s.store.Create(request.Name); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, walletResponse{Name: request.Name})
+}
+
+func (s *WalletServer) balanceHandler(w http.ResponseWriter, r *http.Request) {
+	s.writeWallet(w, http.StatusOK, r.PathValue("name"))
+}
+
+func (s *WalletServer) depositHandler(w http.ResponseWriter, r *http.Request) {
+	var request amountRequest
+	if !decodeRequest(w, r, &request) {
+		return
+	}
+
+	name := r.PathValue("name")
+	if err := s.store.Deposit(name, request.Amount, request.Memo); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	s.writeWallet(w, http.StatusOK, name)
+}
+
+func (s *WalletServer) withdrawHandler(w http.ResponseWriter, r *http.Request) {
+	var request amountRequest
+	if !decodeRequest(w, r, &request) {
+		return
+	}
+
+	name := r.PathValue("name")
+	if err := s.store.Withdraw(name, request.Amount, request.Memo); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	s.writeWallet(w, http.StatusOK, name)
+}
+
+func (s *WalletServer) transferHandler(w http.ResponseWriter, r *http.Request) {
+	var request transferRequest
+	if !decodeRequest(w, r, &request) {
+		return
+	}
+
+	if err := s.store.Transfer(request.From, request.To, request.Amount, request.Memo); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	// the balances are read after the transfer, so another request may have moved them on already
+	from, _ := s.store.Balance(request.From)
+	to, _ := s.store.Balance(request.To)
+	writeJSON(w, http.StatusOK, transferResponse{
+		From: walletResponse{Name: request.From, Balance: from},
+		To:   walletResponse{Name: request.To, Balance: to},
+	})
+}
+
+func (s *WalletServer) writeWallet(w http.ResponseWriter, status int, name string) {
+	balance, err := s.store.Balance(name)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, status, walletResponse{Name: name, Balance: balance})
+}
+
+func decodeRequest(w http.ResponseWriter, r *http.Request, v any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		writeProblem(w, http.StatusBadRequest, "Invalid request", err.Error())
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("content-type", jsonContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// problem is the body of an application/problem+json response.
+type problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func writeProblem(w http.ResponseWriter, status int, title, detail string) {
+	w.Header().Set("content-type", problemContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem{Type: "about:blank", Title: title, Status: status, Detail: detail})
+}
+
+// writeStoreError turns the wallet's errors into HTTP statuses.
+// errors.Is means an *OverdraftError counts as ErrInsufficientFunds here too.
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrWalletNotFound):
+		writeProblem(w, http.StatusNotFound, "Wallet not found", err.Error())
+	case errors.Is(err, ErrWalletExists):
+		writeProblem(w, http.StatusConflict, "Wallet already exists", err.Error())
+	case errors.Is(err, ErrInsufficientFunds):
+		writeProblem(w, http.StatusConflict, "Insufficient funds", err.Error())
+	case errors.Is(err, ErrDailyLimitExceeded),
+		errors.Is(err, ErrMonthlyLimitExceeded),
+		errors.Is(err, ErrBelowMinimumBalance),
+		errors.Is(err, ErrTransactionTooLarge):
+		writeProblem(w, http.StatusUnprocessableEntity, "Withdrawal not allowed", err.Error())
+	case errors.Is(err, ErrNonPositiveAmount), errors.Is(err, ErrSameWallet):
+		writeProblem(w, http.StatusBadRequest, "Invalid request", err.Error())
+	default:
+		writeProblem(w, http.StatusInternalServerError, "Internal server error", "")
+	}
+}
+
+// Mobile clients retry a POST when they don't hear back, even though the first one may have worked.
+// With an Idempotency-Key header we remember the response to the first request and send the very same
+// response again for any retry, so the deposit or transfer only ever happens once.
+
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+type idempotencyCache struct {
+	mu        sync.Mutex
+	responses map[string]*idempotentResponse
+	clock     Clock
+	ttl       time.Duration
+}
+
+type idempotentResponse struct {
+	// fingerprint is a hash of the method, path and body, so a key can't be reused for a different request
+	fingerprint [sha256.Size]byte
+	// done is closed once the first request has finished and the fields below are filled in
+	done    chan struct{}
+	created time.Time
+	status  int
+	header  http.Header
+	body    []byte
+}
+
+func newIdempotencyCache(clock Clock, ttl time.Duration) *idempotencyCache {
+	if clock == nil {
+		clock = ClockFunc(time.Now)
+	}
+	return &idempotencyCache{responses: map[string]*idempotentResponse{}, clock: clock, ttl: ttl}
+}
+
+func (c *idempotencyCache) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" || r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, "Invalid request", err.Error())
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		fingerprint := sha256.Sum256([]byte(fmt.Sprintf("%s %s\n%s", r.Method, r.URL.Path, body)))
+
+		cached, first := c.claim(key, fingerprint)
+		if !first {
+			c.replay(w, cached, fingerprint)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			// a handler that panicked never finished, so treat it like a server error and let
+			// retries run for real, rather than answering "in progress" to them forever
+			if err := recover(); err != nil {
+				recorder.status = http.StatusInternalServerError
+				c.finish(key, cached, recorder)
+				panic(err)
+			}
+		}()
+
+		next.ServeHTTP(recorder, r)
+		c.finish(key, cached, recorder)
+	})
+}
+
+// claim returns the cached response for key and whether this request is the first to use it.
+func (c *idempotencyCache) claim(key string, fingerprint [sha256.Size]byte) (*idempotentResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.Now()
+	for k, cached := range c.responses {
+		if isClosed(cached.done) && now.Sub(cached.created) >= c.ttl {
+			delete(c.responses, k)
+		}
+	}
+
+	if cached, ok := c.responses[key]; ok {
+		return cached, false
+	}
+
+	cached := &idempotentResponse{fingerprint: fingerprint, done: make(chan struct{}), created: now}
+	c.responses[key] = cached
+	return cached, true
+}
+
+func (c *idempotencyCache) replay(w http.ResponseWriter, cached *idempotentResponse, fingerprint [sha256.Size]byte) {
+	if cached.fingerprint != fingerprint {
+		writeProblem(w, http.StatusUnprocessableEntity, "Idempotency key reused", "this Idempotency-Key was already used for a different request")
+		return
+	}
+
+	select {
+	case <-cached.done:
+	default:
+		writeProblem(w, http.StatusConflict, "Request in progress", "a request with this Idempotency-Key is still being processed")
+		return
+	}
+
+	for name, values := range cached.header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(cached.status)
+	w.Write(cached.body)
+}
+
+// finish stores the response for next time, unless it was a server error
+// which the client should be free to retry for real.
+func (c *idempotencyCache) finish(key string, cached *idempotentResponse, recorder *responseRecorder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if recorder.status >= http.StatusInternalServerError {
+		delete(c.responses, key)
+	} else {
+		cached.status = recorder.status
+		cached.header = recorder.Header().Clone()
+		cached.body = recorder.body.Bytes()
+	}
+	close(cached.done)
+}
+
+func isClosed(done chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
+
+// responseRecorder passes the response through to the client while keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
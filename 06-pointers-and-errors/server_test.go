@@ -0,0 +1,282 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWalletServer(t *testing.T) {
+	newServer := func() (*WalletServer, *InMemoryWalletStore) {
+		store := NewInMemoryWalletStore(nil, MaxTransaction{Max: 100})
+		store.Create("alice")
+		store.Create("bob")
+		store.Deposit("alice", Bitcoin(200), "")
+		return NewWalletServer(store, nil), store
+	}
+
+	t.Run("creates a wallet", func(t *testing.T) {
+		server, store := newServer()
+
+		response := serve(server, newWalletRequest(http.MethodPost, "/wallets", `{"name": "carol"}`))
+
+		assertStatus(t, response, http.StatusCreated)
+		assertWalletResponse(t, response, walletResponse{Name: "carol", Balance: 0})
+		if _, err := store.Balance("carol"); err != nil {
+			t.Errorf("carol should be in the store: %v", err)
+		}
+	})
+
+	t.Run("gets a balance", func(t *testing.T) {
+		server, _ := newServer()
+
+		response := serve(server, newWalletRequest(http.MethodGet, "/wallets/alice", ""))
+
+		assertStatus(t, response, http.StatusOK)
+		assertWalletResponse(t, response, walletResponse{Name: "alice", Balance: 200})
+	})
+
+	t.Run("deposits and withdraws", func(t *testing.T) {
+		server, store := newServer()
+
+		response := serve(server, newWalletRequest(http.MethodPost, "/wallets/bob/deposit", `{"amount": 30, "memo": "gift"}`))
+		assertStatus(t, response, http.StatusOK)
+		assertWalletResponse(t, response, walletResponse{Name: "bob", Balance: 30})
+
+		response = serve(server, newWalletRequest(http.MethodPost, "/wallets/bob/withdraw", `{"amount": 10}`))
+		assertStatus(t, response, http.StatusOK)
+		assertWalletResponse(t, response, walletResponse{Name: "bob", Balance: 20})
+
+		ledger, _ := store.Ledger("bob")
+		if len(ledger) != 2 || ledger[0].Memo != "gift" {
+			t.Errorf("got %+v", ledger)
+		}
+	})
+
+	t.Run("transfers", func(t *testing.T) {
+		server, _ := newServer()
+
+		response := serve(server, newWalletRequest(http.MethodPost, "/transfers", `{"from": "alice", "to": "bob", "amount": 20}`))
+
+		assertStatus(t, response, http.StatusOK)
+		var got transferResponse
+		json.NewDecoder(response.Body).Decode(&got)
+		want := transferResponse{From: walletResponse{"alice", 180}, To: walletResponse{"bob", 20}}
+		if got != want {
+			t.Errorf("got %+v want %+v", got, want)
+		}
+	})
+
+	t.Run("maps errors to problem responses", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			method string
+			path   string
+			body   string
+			status int
+		}{
+			{"insufficient funds", http.MethodPost, "/wallets/alice/withdraw", `{"amount": 300}`, http.StatusConflict},
+			{"insufficient funds on transfer", http.MethodPost, "/transfers", `{"from": "bob", "to": "alice", "amount": 1}`, http.StatusConflict},
+			{"policy", http.MethodPost, "/wallets/alice/withdraw", `{"amount": 101}`, http.StatusUnprocessableEntity},
+			{"unknown wallet", http.MethodGet, "/wallets/nobody", "", http.StatusNotFound},
+			{"unknown wallet on deposit", http.MethodPost, "/wallets/nobody/deposit", `{"amount": 1}`, http.StatusNotFound},
+			{"duplicate wallet", http.MethodPost, "/wallets", `{"name": "alice"}`, http.StatusConflict},
+			{"negative amount", http.MethodPost, "/wallets/alice/deposit", `{"amount": -1}`, http.StatusBadRequest},
+			{"same wallet", http.MethodPost, "/transfers", `{"from": "alice", "to": "alice", "amount": 1}`, http.StatusBadRequest},
+			{"bad json", http.MethodPost, "/wallets/alice/deposit", `{"amount": "lots"}`, http.StatusBadRequest},
+			{"unknown field", http.MethodPost, "/wallets/alice/deposit", `{"amonut": 1}`, http.StatusBadRequest},
+			{"missing name", http.MethodPost, "/wallets", `{}`, http.StatusBadRequest},
+		}
+
+		for _, tt := range cases {
+			t.Run(tt.name, func(t *testing.T) {
+				server, _ := newServer()
+
+				response := serve(server, newWalletRequest(tt.method, tt.path, tt.body))
+
+				assertStatus(t, response, tt.status)
+				if got := response.Header().Get("content-type"); got != problemContentType {
+					t.Errorf("got content-type %q want %q", got, problemContentType)
+				}
+				var got problem
+				if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+					t.Fatal(err)
+				}
+				if got.Status != tt.status || got.Title == "" || got.Type != "about:blank" {
+					t.Errorf("got %+v", got)
+				}
+			})
+		}
+	})
+}
+
+func TestIdempotencyKeys(t *testing.T) {
+	t.Run("a retried deposit only happens once", func(t *testing.T) {
+		store := NewInMemoryWalletStore(nil)
+		store.Create("alice")
+		server := NewWalletServer(store, nil)
+
+		first := serve(server, newIdempotentRequest("/wallets/alice/deposit", `{"amount": 10}`, "key-1"))
+		retry := serve(server, newIdempotentRequest("/wallets/alice/deposit", `{"amount": 10}`, "key-1"))
+
+		assertStatus(t, first, http.StatusOK)
+		assertStatus(t, retry, http.StatusOK)
+		if first.Body.String() != retry.Body.String() {
+			t.Errorf("retry got %q want %q", retry.Body.String(), first.Body.String())
+		}
+		if retry.Header().Get("Idempotent-Replayed") != "true" {
+			t.Error("retry should be marked as replayed")
+		}
+		assertStoreBalance(t, store, "alice", Bitcoin(10))
+
+		serve(server, newIdempotentRequest("/wallets/alice/deposit", `{"amount": 10}`, "key-2"))
+		assertStoreBalance(t, store, "alice", Bitcoin(20))
+	})
+
+	t.Run("errors are replayed too", func(t *testing.T) {
+		store := NewInMemoryWalletStore(nil)
+		store.Create("alice")
+		server := NewWalletServer(store, nil)
+
+		first := serve(server, newIdempotentRequest("/wallets/alice/withdraw", `{"amount": 10}`, "key-1"))
+		store.Deposit("alice", Bitcoin(100), "")
+		retry := serve(server, newIdempotentRequest("/wallets/alice/withdraw", `{"amount": 10}`, "key-1"))
+
+		assertStatus(t, first, http.StatusConflict)
+		assertStatus(t, retry, http.StatusConflict)
+		assertStoreBalance(t, store, "alice", Bitcoin(100))
+	})
+
+	t.Run("a key can't be reused for a different request", func(t *testing.T) {
+		store := NewInMemoryWalletStore(nil)
+		store.Create("alice")
+		server := NewWalletServer(store, nil)
+
+		serve(server, newIdempotentRequest("/wallets/alice/deposit", `{"amount": 10}`, "key-1"))
+		response := serve(server, newIdempotentRequest("/wallets/alice/deposit", `{"amount": 99}`, "key-1"))
+
+		assertStatus(t, response, http.StatusUnprocessableEntity)
+		assertStoreBalance(t, store, "alice", Bitcoin(10))
+	})
+
+	t.Run("keys expire", func(t *testing.T) {
+		clock := &ManualClock{now: start}
+		store := NewInMemoryWalletStore(nil)
+		store.Create("alice")
+		server := NewWalletServer(store, clock)
+
+		serve(server, newIdempotentRequest("/wallets/alice/deposit", `{"amount": 10}`, "key-1"))
+		clock.Advance(DefaultIdempotencyTTL)
+		serve(server, newIdempotentRequest("/wallets/alice/deposit", `{"amount": 10}`, "key-1"))
+
+		assertStoreBalance(t, store, "alice", Bitcoin(20))
+	})
+
+	t.Run("a retry after a panic runs for real", func(t *testing.T) {
+		calls := 0
+		handler := newIdempotencyCache(nil, DefaultIdempotencyTTL).middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls == 1 {
+				panic("the first attempt fell over")
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		func() {
+			defer func() {
+				if err := recover(); err == nil {
+					t.Error("the panic should carry on up to net/http")
+				}
+			}()
+			serve(handler, newIdempotentRequest("/wallets/alice/deposit", `{"amount": 10}`, "key-1"))
+		}()
+		retry := serve(handler, newIdempotentRequest("/wallets/alice/deposit", `{"amount": 10}`, "key-1"))
+
+		assertStatus(t, retry, http.StatusOK)
+		if calls != 2 {
+			t.Errorf("handler ran %d times want 2", calls)
+		}
+	})
+
+	t.Run("aggressive concurrent retries still apply once", func(t *testing.T) {
+		store := NewInMemoryWalletStore(nil)
+		store.Create("alice")
+		server := NewWalletServer(store, nil)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					response := serve(server, newIdempotentRequest("/wallets/alice/deposit", `{"amount": 10}`, "key-1"))
+					// 409 means the first attempt is still running, so the client tries again
+					if response.Code != http.StatusConflict {
+						return
+					}
+					time.Sleep(time.Millisecond)
+				}
+			}()
+		}
+		wg.Wait()
+
+		assertStoreBalance(t, store, "alice", Bitcoin(10))
+	})
+}
+
+func serve(server http.Handler, request *http.Request) *httptest.ResponseRecorder {
+	response := httptest.NewRecorder()
+	server.ServeHTTP(response, request)
+	return response
+}
+
+func newWalletRequest(method, path, body string) *http.Request {
+	request, _ := http.NewRequest(method, path, strings.NewReader(body))
+	return request
+}
+
+func newIdempotentRequest(path, body, key string) *http.Request {
+	request := newWalletRequest(http.MethodPost, path, body)
+	request.Header.Set("Idempotency-Key", key)
+	return request
+}
+
+func assertStatus(t testing.TB, response *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if response.Code != want {
+		t.Errorf("did not get correct status, got %d, want %d: %s", response.Code, want, response.Body)
+	}
+}
+
+func assertWalletResponse(t testing.TB, response *httptest.ResponseRecorder, want walletResponse) {
+	t.Helper()
+	if got := response.Header().Get("content-type"); got != jsonContentType {
+		t.Errorf("response did not have content-type of %s, got %s", jsonContentType, got)
+	}
+	var got walletResponse
+	if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+		t.Fatalf("Unable to parse response from server %q, '%v'", response.Body, err)
+	}
+	if got != want {
+		t.Errorf("got %+v want %+v", got, want)
+	}
+}
+
+func assertStoreBalance(t testing.TB, store WalletStore, name string, want Bitcoin) {
+	t.Helper()
+	got, err := store.Balance(name)
+	assertNoError(t, err)
+	if got != want {
+		t.Errorf("%s: got %s want %s", name, got, want)
+	}
+}
+
+// both stores have to be usable wherever a WalletStore is wanted
+var (
+	_ WalletStore = (*InMemoryWalletStore)(nil)
+	_ WalletStore = (*FileSystemWalletStore)(nil)
+)
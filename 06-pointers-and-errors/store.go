@@ -0,0 +1,411 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FileSystemWalletStore keeps named wallets on disk so they survive a restart.
+// Every change goes into a write-ahead log (see wal.go) before it touches the wallet in memory.
+// Compact writes everything out to a snapshot and empties the log, so it doesn't grow forever.
+//
+// The store hands out balances and ledgers but never the *Wallet itself:
+// a change made straight on a wallet would skip the log and be lost on restart.
+// Holds are not persisted, they are short lived reservations that lapse on their own.
+type FileSystemWalletStore struct {
+	// mu makes every change a single step: check, log, then apply
+	mu       sync.Mutex
+	dir      string
+	log      *wal
+	lastSeq  uint64
+	wallets  map[string]*Wallet
+	clock    Clock
+	policies []Policy
+}
+
+const (
+	walFileName      = "wallets.wal"
+	snapshotFileName = "wallets.snapshot"
+)
+
+var (
+	ErrWalletNotFound = errors.New("wallet not found")
+	ErrWalletExists   = errors.New("wallet already exists")
+)
+
+// snapshot is the whole store as of LastSeq, the WAL only needs replaying from after it.
+type snapshot struct {
+	LastSeq uint64             `json:"lastSeq"`
+	Wallets map[string][]Entry `json:"wallets"`
+}
+
+// NewFileSystemWalletStore opens the store in dir, creating it if needed, and brings it back
+// to its last state by loading the snapshot and replaying the WAL on top.
+// Every wallet it creates uses clock and has to pass policies.
+func NewFileSystemWalletStore(dir string, clock Clock, policies ...Policy) (*FileSystemWalletStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &FileSystemWalletStore{dir: dir, wallets: map[string]*Wallet{}, clock: clock, policies: policies}
+
+	if err := s.loadSnapshot(); err != nil {
+		return nil, err
+	}
+
+	log, records, err := openWAL(filepath.Join(dir, walFileName))
+	if err != nil {
+		return nil, err
+	}
+	s.log = log
+
+	for _, record := range records {
+		// a crash between writing a snapshot and emptying the WAL leaves records the snapshot already has
+		if record.Seq <= s.lastSeq {
+			continue
+		}
+		if err := s.apply(record); err != nil {
+			log.close()
+			return nil, fmt.Errorf("%w: replaying record %d: %v", ErrCorruptWAL, record.Seq, err)
+		}
+		s.lastSeq = record.Seq
+	}
+
+	return s, nil
+}
+
+func (s *FileSystemWalletStore) loadSnapshot() error {
+	data, err := os.ReadFile(filepath.Join(s.dir, snapshotFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("reading snapshot: %w", err)
+	}
+
+	for name, entries := range snap.Wallets {
+		wallet := s.newWallet()
+		wallet.ledger = entries
+		wallet.balance = Replay(entries)
+		s.wallets[name] = wallet
+	}
+	s.lastSeq = snap.LastSeq
+
+	return nil
+}
+
+func (s *FileSystemWalletStore) newWallet() *Wallet {
+	return NewWallet(s.clock, s.policies...)
+}
+
+// apply makes a record's change in memory without any checks, it is used both
+// for new records once they are logged and for old ones being replayed.
+func (s *FileSystemWalletStore) apply(record walRecord) error {
+	if record.Op == opCreate {
+		if _, ok := s.wallets[record.Wallet]; ok {
+			return ErrWalletExists
+		}
+		s.wallets[record.Wallet] = s.newWallet()
+		return nil
+	}
+
+	wallet, ok := s.wallets[record.Wallet]
+	if !ok {
+		return ErrWalletNotFound
+	}
+
+	// a transfer sets to, and like Transfer we only publish once both locks are released
+	var to *Wallet
+	wallet.mu.Lock()
+	defer func() {
+		wallet.unlock()
+		if to != nil {
+			to.publish()
+		}
+	}()
+
+	switch record.Op {
+	case opDeposit:
+		wallet.balance += record.Amount
+		wallet.record(record.Time, KindDeposit, record.Amount, record.Memo)
+	case opWithdraw:
+		wallet.balance -= record.Amount
+		wallet.record(record.Time, KindWithdrawal, -record.Amount, record.Memo)
+	case opTransfer:
+		receiver, ok := s.wallets[record.To]
+		if !ok {
+			return ErrWalletNotFound
+		}
+		// only the store touches these wallets and it is holding s.mu, so taking
+		// the second lock can't deadlock against another transfer
+		receiver.mu.Lock()
+		wallet.balance -= record.Amount
+		wallet.record(record.Time, KindTransferOut, -record.Amount, record.Memo)
+		receiver.balance += record.Amount
+		receiver.record(record.Time, KindTransferIn, record.Amount, record.Memo)
+		receiver.mu.Unlock()
+		to = receiver
+	case opAccrue:
+		if record.Kind != KindInterest && record.Kind != KindFee {
+			return fmt.Errorf("unknown accrual kind %q", record.Kind)
+		}
+		wallet.balance += record.Amount
+		wallet.record(record.Time, record.Kind, record.Amount, record.Memo)
+	default:
+		return fmt.Errorf("unknown operation %q", record.Op)
+	}
+
+	return nil
+}
+
+// commit logs the record and then applies it. Callers hold s.mu and have already checked it is allowed.
+func (s *FileSystemWalletStore) commit(record walRecord) error {
+	record.Seq = s.lastSeq + 1
+	if err := s.log.append(record); err != nil {
+		return err
+	}
+	s.lastSeq = record.Seq
+	return s.apply(record)
+}
+
+func (s *FileSystemWalletStore) now() time.Time {
+	if s.clock == nil {
+		return time.Now()
+	}
+	return s.clock.Now()
+}
+
+func (s *FileSystemWalletStore) wallet(name string) (*Wallet, error) {
+	wallet, ok := s.wallets[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrWalletNotFound, name)
+	}
+	return wallet, nil
+}
+
+func (s *FileSystemWalletStore) Create(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.wallets[name]; ok {
+		return fmt.Errorf("%w: %q", ErrWalletExists, name)
+	}
+	return s.commit(walRecord{Op: opCreate, Wallet: name, Time: s.now()})
+}
+
+func (s *FileSystemWalletStore) Deposit(name string, amount Bitcoin, memo string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.wallet(name); err != nil {
+		return err
+	}
+	if err := checkPositive(amount); err != nil {
+		return err
+	}
+	return s.commit(walRecord{Op: opDeposit, Wallet: name, Amount: amount, Memo: memo, Time: s.now()})
+}
+
+func (s *FileSystemWalletStore) Withdraw(name string, amount Bitcoin, memo string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wallet, err := s.wallet(name)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	if err := s.checkWithdrawal(wallet, amount, now); err != nil {
+		return err
+	}
+	return s.commit(walRecord{Op: opWithdraw, Wallet: name, Amount: amount, Memo: memo, Time: now})
+}
+
+func (s *FileSystemWalletStore) Transfer(from, to string, amount Bitcoin, memo string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	source, err := s.wallet(from)
+	if err != nil {
+		return err
+	}
+	if _, err := s.wallet(to); err != nil {
+		return err
+	}
+	if from == to {
+		return ErrSameWallet
+	}
+
+	now := s.now()
+	if err := s.checkWithdrawal(source, amount, now); err != nil {
+		return err
+	}
+	return s.commit(walRecord{Op: opTransfer, Wallet: from, To: to, Amount: amount, Memo: memo, Time: now})
+}
+
+// Accrue is Wallet.Accrue for a wallet in the store. Every period is logged before it is
+// applied, so interest and fees survive a restart like any other change.
+func (s *FileSystemWalletStore) Accrue(name string, schedule Schedule, now time.Time) ([]Entry, error) {
+	if err := schedule.validate(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wallet, err := s.wallet(name)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet.mu.Lock()
+	due := wallet.dueAccruals(schedule, now)
+	at := wallet.accrualTime(now)
+	wallet.mu.Unlock()
+
+	var recorded []Entry
+	for _, a := range due {
+		if err := s.commit(walRecord{Op: opAccrue, Wallet: name, Kind: a.kind, Amount: a.amount, Memo: a.memo, Time: at}); err != nil {
+			return recorded, err
+		}
+		recorded = append(recorded, s.lastEntry(wallet))
+	}
+	return recorded, nil
+}
+
+// Accruer lets a Scheduler run schedules against the named wallet through the store,
+// rather than on the *Wallet where the entries would never reach the log.
+func (s *FileSystemWalletStore) Accruer(name string) Accruer {
+	return storedWallet{store: s, name: name}
+}
+
+type storedWallet struct {
+	store *FileSystemWalletStore
+	name  string
+}
+
+func (w storedWallet) Accrue(schedule Schedule, now time.Time) ([]Entry, error) {
+	return w.store.Accrue(w.name, schedule, now)
+}
+
+func (s *FileSystemWalletStore) lastEntry(wallet *Wallet) Entry {
+	wallet.mu.Lock()
+	defer wallet.mu.Unlock()
+
+	return wallet.ledger[len(wallet.ledger)-1]
+}
+
+func (s *FileSystemWalletStore) checkWithdrawal(wallet *Wallet, amount Bitcoin, now time.Time) error {
+	wallet.mu.Lock()
+	defer wallet.mu.Unlock()
+
+	return wallet.checkWithdrawal(amount, now)
+}
+
+func (s *FileSystemWalletStore) Balance(name string) (Bitcoin, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wallet, err := s.wallet(name)
+	if err != nil {
+		return 0, err
+	}
+	return wallet.Balance(), nil
+}
+
+func (s *FileSystemWalletStore) Ledger(name string) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wallet, err := s.wallet(name)
+	if err != nil {
+		return nil, err
+	}
+	return wallet.Ledger(), nil
+}
+
+func (s *FileSystemWalletStore) Names() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.wallets))
+	for name := range s.wallets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Compact writes a snapshot of every wallet and then empties the WAL.
+// The snapshot goes to a temporary file that is renamed into place,
+// so a crash part way through leaves either the old snapshot or the new one, never half of one.
+func (s *FileSystemWalletStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := snapshot{LastSeq: s.lastSeq, Wallets: map[string][]Entry{}}
+	for name, wallet := range s.wallets {
+		snap.Wallets[name] = wallet.Ledger()
+	}
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomically(filepath.Join(s.dir, snapshotFileName), data); err != nil {
+		return err
+	}
+
+	return s.log.reset()
+}
+
+func (s *FileSystemWalletStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.log.close()
+}
+
+func writeFileAtomically(path string, data []byte) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	// if anything goes wrong the temporary file is all we leave behind, and we tidy that up
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return err
+	}
+
+	// the rename is only durable once the directory itself has been synced
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestFileSystemWalletStore(t *testing.T) {
+	t.Run("wallets survive a restart", func(t *testing.T) {
+		dir := t.TempDir()
+		store, err := NewFileSystemWalletStore(dir, &StubClock{now: start})
+		assertNoError(t, err)
+
+		assertNoError(t, store.Create("alice"))
+		assertNoError(t, store.Create("bob"))
+		assertNoError(t, store.Deposit("alice", Bitcoin(50), "salary"))
+		assertNoError(t, store.Withdraw("alice", Bitcoin(5), ""))
+		assertNoError(t, store.Transfer("alice", "bob", Bitcoin(20), "dinner"))
+		want, _ := store.Ledger("alice")
+		assertNoError(t, store.Close())
+
+		store = openStore(t, dir)
+		defer store.Close()
+
+		assertStoreBalance(t, store, "alice", Bitcoin(25))
+		assertStoreBalance(t, store, "bob", Bitcoin(20))
+		got, _ := store.Ledger("alice")
+		assertEntries(t, got, want)
+	})
+
+	t.Run("interest and fees survive a restart", func(t *testing.T) {
+		dir := t.TempDir()
+		clock := &ManualClock{now: start}
+		store, err := NewFileSystemWalletStore(dir, clock)
+		assertNoError(t, err)
+		assertNoError(t, store.Create("alice"))
+		assertNoError(t, store.Deposit("alice", Bitcoin(1000), ""))
+		schedule := Schedule{Name: "savings", Start: start, Every: day, Rule: CompoundInterest{BasisPoints: 100}}
+
+		clock.Advance(2 * day)
+		scheduler := NewScheduler(clock)
+		assertNoError(t, scheduler.Add(store.Accruer("alice"), schedule))
+		entries, err := scheduler.RunDue()
+		assertNoError(t, err)
+		if len(entries) != 2 {
+			t.Fatalf("got %+v want two periods", entries)
+		}
+		want, _ := store.Ledger("alice")
+		assertNoError(t, store.Close())
+
+		store, err = NewFileSystemWalletStore(dir, clock)
+		assertNoError(t, err)
+		defer store.Close()
+
+		assertStoreBalance(t, store, "alice", Bitcoin(1020))
+		got, _ := store.Ledger("alice")
+		assertEntries(t, got, want)
+
+		// the periods it has already paid came back with the ledger, so they aren't paid again
+		entries, err = store.Accrue("alice", schedule, clock.Now())
+		assertNoError(t, err)
+		if len(entries) != 0 {
+			t.Errorf("got %+v want nothing due", entries)
+		}
+	})
+
+	t.Run("failed operations are not logged", func(t *testing.T) {
+		dir := t.TempDir()
+		store := openStore(t, dir)
+
+		store.Create("alice")
+		if err := store.Create("alice"); !errors.Is(err, ErrWalletExists) {
+			t.Errorf("got %v want %v", err, ErrWalletExists)
+		}
+		if err := store.Deposit("nobody", Bitcoin(10), ""); !errors.Is(err, ErrWalletNotFound) {
+			t.Errorf("got %v want %v", err, ErrWalletNotFound)
+		}
+		assertError(t, store.Withdraw("alice", Bitcoin(10), ""), ErrInsufficientFunds)
+		if err := store.Deposit("alice", Bitcoin(-10), ""); !errors.Is(err, ErrNonPositiveAmount) {
+			t.Errorf("got %v want %v", err, ErrNonPositiveAmount)
+		}
+		store.Close()
+
+		_, records, err := openWAL(dir + "/" + walFileName)
+		assertNoError(t, err)
+		if len(records) != 1 {
+			t.Errorf("got %d records want just the create", len(records))
+		}
+	})
+
+	t.Run("wallets get the store's policies", func(t *testing.T) {
+		store, err := NewFileSystemWalletStore(t.TempDir(), nil, MaxTransaction{Max: 10})
+		assertNoError(t, err)
+		defer store.Close()
+
+		store.Create("alice")
+		store.Deposit("alice", Bitcoin(100), "")
+
+		if err := store.Withdraw("alice", Bitcoin(11), ""); !errors.Is(err, ErrTransactionTooLarge) {
+			t.Errorf("got %v want %v", err, ErrTransactionTooLarge)
+		}
+	})
+
+	t.Run("compaction moves everything into a snapshot", func(t *testing.T) {
+		dir := t.TempDir()
+		store := openStore(t, dir)
+
+		store.Create("alice")
+		store.Deposit("alice", Bitcoin(10), "")
+		assertNoError(t, store.Compact())
+		if size := fileSize(t, dir+"/"+walFileName); size != 0 {
+			t.Errorf("WAL should be empty after compaction, it is %d bytes", size)
+		}
+
+		store.Deposit("alice", Bitcoin(5), "after the snapshot")
+		store.Close()
+
+		store = openStore(t, dir)
+		defer store.Close()
+
+		assertStoreBalance(t, store, "alice", Bitcoin(15))
+		ledger, _ := store.Ledger("alice")
+		if len(ledger) != 2 || ledger[1].ID != 2 || ledger[1].Memo != "after the snapshot" {
+			t.Errorf("got %+v", ledger)
+		}
+	})
+
+	t.Run("records already in the snapshot aren't replayed twice", func(t *testing.T) {
+		dir := t.TempDir()
+		store := openStore(t, dir)
+		store.Create("alice")
+		store.Deposit("alice", Bitcoin(10), "")
+		store.Close()
+
+		// pretend we crashed after the snapshot was written but before the WAL was emptied
+		wal, _ := os.ReadFile(dir + "/" + walFileName)
+		store = openStore(t, dir)
+		assertNoError(t, store.Compact())
+		store.Close()
+		assertNoError(t, os.WriteFile(dir+"/"+walFileName, wal, 0o644))
+
+		store = openStore(t, dir)
+		defer store.Close()
+		assertStoreBalance(t, store, "alice", Bitcoin(10))
+	})
+}
+
+// The crash test runs this in a child process, which writes to the store as fast
+// as it can until the parent kills it.
+func TestStoreCrashHelper(t *testing.T) {
+	dir := os.Getenv("WALLET_STORE_CRASH_DIR")
+	if dir == "" {
+		t.Skip("only runs as a child of TestStoreSurvivesBeingKilled")
+	}
+
+	store, err := NewFileSystemWalletStore(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Create("alice")
+	store.Create("bob")
+
+	os.Stdout.WriteString("ready\n")
+	for i := 0; ; i++ {
+		store.Deposit("alice", Bitcoin(3), "top up")
+		store.Transfer("alice", "bob", Bitcoin(2), "")
+		store.Withdraw("bob", Bitcoin(1), "")
+		if i%50 == 49 {
+			store.Compact()
+		}
+	}
+}
+
+func TestStoreSurvivesBeingKilled(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts child processes")
+	}
+
+	dir := t.TempDir()
+
+	for run := 0; run < 5; run++ {
+		child := exec.Command(os.Args[0], "-test.run=^TestStoreCrashHelper$")
+		child.Env = append(os.Environ(), "WALLET_STORE_CRASH_DIR="+dir)
+		stdout, err := child.StdoutPipe()
+		assertNoError(t, err)
+		assertNoError(t, child.Start())
+
+		if !bufio.NewScanner(stdout).Scan() {
+			t.Fatal("child process never got going")
+		}
+		time.Sleep(time.Duration(20+run*15) * time.Millisecond)
+		assertNoError(t, child.Process.Kill())
+		child.Wait()
+
+		store := openStore(t, dir)
+
+		var supply, deposited Bitcoin
+		for _, name := range store.Names() {
+			balance, _ := store.Balance(name)
+			ledger, _ := store.Ledger(name)
+			if replay := Replay(ledger); replay != balance {
+				t.Fatalf("run %d: %s has balance %s but its ledger adds up to %s", run, name, balance, replay)
+			}
+			for _, e := range ledger {
+				switch e.Kind {
+				case KindDeposit:
+					deposited += e.Amount
+				case KindWithdrawal:
+					deposited += e.Amount
+				}
+			}
+			supply += balance
+		}
+		// transfers only move money about, so whatever is left must be what came in minus what went out
+		if supply != deposited {
+			t.Fatalf("run %d: wallets hold %s but deposits less withdrawals come to %s", run, supply, deposited)
+		}
+		store.Close()
+	}
+}
+
+func openStore(t testing.TB, dir string) *FileSystemWalletStore {
+	t.Helper()
+	store, err := NewFileSystemWalletStore(dir, nil)
+	if err != nil {
+		t.Fatalf("could not open store: %v", err)
+	}
+	return store
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+)
+
+// Polling Balance misses whatever happened in between two polls.
+// Subscribing gets you every ledger entry as it is written instead,
+// which carries the kind of change, the amount and the new balance.
+//
+// Entries are delivered after the wallet's lock is released, so a subscriber is free to
+// call Balance or anything else on the wallet while it handles them, even with Block.
+
+// OverflowPolicy decides what happens when a subscriber's buffer is full.
+type OverflowPolicy int
+
+const (
+	// DropNewest throws the new event away and counts it in Dropped, the wallet never waits.
+	DropNewest OverflowPolicy = iota
+	// Block makes whoever changed the wallet wait until the subscriber catches up (or goes away)
+	// before their call returns. Other changes can still be made in the meantime.
+	Block
+)
+
+type Subscription struct {
+	// Events is closed once the subscription's context is done or Unsubscribe is called
+	Events <-chan Entry
+
+	events  chan Entry
+	policy  OverflowPolicy
+	ctx     context.Context
+	cancel  context.CancelFunc
+	closed  chan struct{}
+	dropped atomic.Uint64
+}
+
+// Dropped is how many events were thrown away because the buffer was full.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Unsubscribe stops the events, and once it returns Events has been closed.
+// It's the same as cancelling the context given to Subscribe.
+func (s *Subscription) Unsubscribe() {
+	s.cancel()
+	<-s.closed
+}
+
+// Subscribe sends every balance change from now on to the returned Subscription,
+// buffering up to buffer events, until ctx is cancelled or it is unsubscribed.
+func (w *Wallet) Subscribe(ctx context.Context, buffer int, policy OverflowPolicy) *Subscription {
+	ctx, cancel := context.WithCancel(ctx)
+	events := make(chan Entry, max(buffer, 0))
+	sub := &Subscription{Events: events, events: events, policy: policy, ctx: ctx, cancel: cancel, closed: make(chan struct{})}
+
+	w.mu.Lock()
+	w.subscribers = append(w.subscribers, sub)
+	w.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		w.mu.Lock()
+		if i := slices.Index(w.subscribers, sub); i >= 0 {
+			w.subscribers = slices.Delete(w.subscribers, i, i+1)
+		}
+		w.mu.Unlock()
+
+		// publish only sends while holding w.publishing, so once we have it nobody
+		// can be sending on the channel, and nobody will again now sub is gone
+		w.publishing.Lock()
+		close(events)
+		w.publishing.Unlock()
+		close(sub.closed)
+	}()
+
+	return sub
+}
+
+// unlock releases w.mu and then delivers the entries recorded while it was held.
+// Anything that records must unlock this way rather than with w.mu.Unlock.
+func (w *Wallet) unlock() {
+	w.mu.Unlock()
+	w.publish()
+}
+
+// publish must be called without w.mu held. The entries are taken in the order they were
+// recorded, and w.publishing makes sure whoever takes them delivers them before anyone
+// takes the next ones, so every subscriber sees them in ledger order.
+func (w *Wallet) publish() {
+	w.publishing.Lock()
+	defer w.publishing.Unlock()
+
+	w.mu.Lock()
+	entries := w.pending
+	w.pending = nil
+	subscribers := slices.Clone(w.subscribers)
+	w.mu.Unlock()
+
+	for _, entry := range entries {
+		for _, sub := range subscribers {
+			sub.send(entry)
+		}
+	}
+}
+
+func (s *Subscription) send(entry Entry) {
+	if s.ctx.Err() != nil {
+		return
+	}
+
+	switch s.policy {
+	case Block:
+		select {
+		case s.events <- entry:
+		case <-s.ctx.Done():
+		}
+	default:
+		select {
+		case s.events <- entry:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
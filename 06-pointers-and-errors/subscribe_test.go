@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	t.Run("subscribers see every change", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		wallet, other := &Wallet{}, &Wallet{}
+		sub := wallet.Subscribe(ctx, 10, DropNewest)
+
+		wallet.Deposit(Bitcoin(20))
+		wallet.Withdraw(Bitcoin(5))
+		Transfer(wallet, other, Bitcoin(3))
+		wallet.Withdraw(Bitcoin(100)) // fails, so no event
+
+		want := []struct {
+			kind    EntryKind
+			amount  Bitcoin
+			balance Bitcoin
+		}{
+			{KindDeposit, 20, 20},
+			{KindWithdrawal, -5, 15},
+			{KindTransferOut, -3, 12},
+		}
+		for _, w := range want {
+			got := receive(t, sub)
+			if got.Kind != w.kind || got.Amount != w.amount || got.Balance != w.balance {
+				t.Errorf("got %+v want %+v", got, w)
+			}
+		}
+		assertNoEvent(t, sub)
+	})
+
+	t.Run("a full buffer drops new events when asked to", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		wallet := &Wallet{}
+		sub := wallet.Subscribe(ctx, 2, DropNewest)
+
+		for i := 1; i <= 5; i++ {
+			wallet.Deposit(Bitcoin(i))
+		}
+
+		if got := receive(t, sub).Amount; got != 1 {
+			t.Errorf("got %s want the first deposit", got)
+		}
+		if got := receive(t, sub).Amount; got != 2 {
+			t.Errorf("got %s want the second deposit", got)
+		}
+		assertNoEvent(t, sub)
+		if sub.Dropped() != 3 {
+			t.Errorf("got %d dropped want 3", sub.Dropped())
+		}
+		assertBalance(t, wallet, Bitcoin(15))
+	})
+
+	t.Run("a full buffer makes the wallet wait when asked to", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		wallet := &Wallet{}
+		sub := wallet.Subscribe(ctx, 1, Block)
+
+		wallet.Deposit(Bitcoin(1))
+		deposited := make(chan struct{})
+		go func() {
+			wallet.Deposit(Bitcoin(2))
+			close(deposited)
+		}()
+
+		select {
+		case <-deposited:
+			t.Fatal("deposit should wait for the subscriber")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		receive(t, sub)
+		<-deposited
+		if got := receive(t, sub).Amount; got != 2 {
+			t.Errorf("got %s want 2", got)
+		}
+	})
+
+	t.Run("cancelling the context unsubscribes and closes the channel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		wallet := &Wallet{}
+		sub := wallet.Subscribe(ctx, 0, Block)
+
+		cancel()
+		for range sub.Events {
+		}
+
+		// a blocking subscriber that has gone away must not hold the wallet up
+		wallet.Deposit(Bitcoin(1))
+		assertBalance(t, wallet, Bitcoin(1))
+		wallet.mu.Lock()
+		defer wallet.mu.Unlock()
+		if len(wallet.subscribers) != 0 {
+			t.Errorf("still got %d subscribers", len(wallet.subscribers))
+		}
+	})
+
+	t.Run("unsubscribing closes the channel without cancelling the context", func(t *testing.T) {
+		wallet := &Wallet{}
+		sub := wallet.Subscribe(context.Background(), 0, Block)
+
+		sub.Unsubscribe()
+
+		if _, ok := <-sub.Events; ok {
+			t.Error("want Events closed")
+		}
+		wallet.Deposit(Bitcoin(1))
+		assertBalance(t, wallet, Bitcoin(1))
+		wallet.mu.Lock()
+		defer wallet.mu.Unlock()
+		if len(wallet.subscribers) != 0 {
+			t.Errorf("still got %d subscribers", len(wallet.subscribers))
+		}
+	})
+
+	t.Run("a blocking subscriber can use the wallet while it reads", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		wallet, other := &Wallet{}, &Wallet{}
+		wallet.Deposit(Bitcoin(100))
+		sub := wallet.Subscribe(ctx, 1, Block)
+		otherSub := other.Subscribe(ctx, 1, Block)
+
+		balances := make(chan Bitcoin, 20)
+		consume := func(sub *Subscription) {
+			for range sub.Events {
+				// wallet and other are both busy in a transfer while it publishes
+				balances <- wallet.Balance() + other.Balance()
+			}
+		}
+		go consume(sub)
+		go consume(otherSub)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 5; i++ {
+				wallet.Withdraw(Bitcoin(1))
+				Transfer(wallet, other, Bitcoin(1))
+			}
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("deadlocked, the subscriber couldn't read the wallet")
+		}
+		assertBalance(t, wallet, Bitcoin(90))
+		assertBalance(t, other, Bitcoin(5))
+	})
+
+	t.Run("a blocking subscriber misses nothing under contention", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		wallet := &Wallet{}
+		sub := wallet.Subscribe(ctx, 4, Block)
+
+		var total Bitcoin
+		received := make(chan int)
+		go func() {
+			count := 0
+			for e := range sub.Events {
+				total += e.Amount
+				count++
+				if count == 200 {
+					received <- count
+				}
+			}
+		}()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 200; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				wallet.Deposit(Bitcoin(1))
+			}()
+		}
+		wg.Wait()
+
+		<-received
+		if total != wallet.Balance() {
+			t.Errorf("events add up to %s but balance is %s", total, wallet.Balance())
+		}
+	})
+}
+
+func receive(t testing.TB, sub *Subscription) Entry {
+	t.Helper()
+	select {
+	case e, ok := <-sub.Events:
+		if !ok {
+			t.Fatal("subscription closed")
+		}
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for an event")
+		return Entry{}
+	}
+}
+
+func assertNoEvent(t testing.TB, sub *Subscription) {
+	t.Helper()
+	select {
+	case e := <-sub.Events:
+		t.Errorf("didn't want an event, got %+v", e)
+	default:
+	}
+}
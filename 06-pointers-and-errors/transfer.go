@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+var ErrSameWallet = errors.New("cannot transfer, source and destination are the same wallet")
+
+const (
+	KindTransferOut = EntryKind("transfer-out")
+	KindTransferIn  = EntryKind("transfer-in")
+)
+
+func Transfer(from, to *Wallet, amount Bitcoin) error {
+	return TransferWithMemo(from, to, amount, "")
+}
+
+// TransferWithMemo moves money between two wallets while holding both of their locks,
+// so nobody can ever see the money in both wallets or in neither.
+//
+// If one goroutine locks A then B while another locks B then A, each can end up
+// holding one lock and waiting forever for the other. To avoid that deadlock
+// every transfer takes the locks in the same order, lowest wallet id first.
+func TransferWithMemo(from, to *Wallet, amount Bitcoin, memo string) error {
+	if from == to {
+		return ErrSameWallet
+	}
+	if err := checkPositive(amount); err != nil {
+		return err
+	}
+
+	first, second := from, to
+	if second.lockOrder() < first.lockOrder() {
+		first, second = second, first
+	}
+
+	first.mu.Lock()
+	second.mu.Lock()
+	defer func() {
+		// both locks go before either wallet publishes, so a subscriber to one can use the other
+		second.mu.Unlock()
+		first.mu.Unlock()
+		from.publish()
+		to.publish()
+	}()
+
+	// a transfer takes money out of from just like Withdraw does, so it has to pass the same checks
+	now := from.now()
+	if err := from.checkWithdrawal(amount, now); err != nil {
+		return err
+	}
+
+	from.balance -= amount
+	from.record(now, KindTransferOut, -amount, memo)
+	to.balance += amount
+	to.record(now, KindTransferIn, amount, memo)
+
+	return nil
+}
+
+var lastWalletID atomic.Uint64
+
+// lockOrder gives every wallet a unique number the first time it takes part in a transfer.
+// We can't hand them out in NewWallet because the zero value Wallet has to work too.
+func (w *Wallet) lockOrder() uint64 {
+	if id := w.id.Load(); id != 0 {
+		return id
+	}
+	// if two transfers race to number the same wallet only one CompareAndSwap wins,
+	// and both then read back the winner's number
+	w.id.CompareAndSwap(0, lastWalletID.Add(1))
+	return w.id.Load()
+}
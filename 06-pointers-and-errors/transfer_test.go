@@ -0,0 +1,113 @@
+package main
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestTransfer(t *testing.T) {
+	t.Run("moves money between wallets", func(t *testing.T) {
+		from, to := &Wallet{}, &Wallet{}
+		from.Deposit(Bitcoin(20))
+
+		err := TransferWithMemo(from, to, Bitcoin(15), "lunch")
+
+		assertNoError(t, err)
+		assertBalance(t, from, Bitcoin(5))
+		assertBalance(t, to, Bitcoin(15))
+
+		out, in := from.Ledger()[1], to.Ledger()[0]
+		if out.Kind != KindTransferOut || out.Amount != -15 || out.Memo != "lunch" {
+			t.Errorf("got %+v", out)
+		}
+		if in.Kind != KindTransferIn || in.Amount != 15 || in.Memo != "lunch" {
+			t.Errorf("got %+v", in)
+		}
+	})
+
+	t.Run("insufficient funds leaves both wallets alone", func(t *testing.T) {
+		from, to := &Wallet{}, &Wallet{}
+		from.Deposit(Bitcoin(20))
+
+		err := Transfer(from, to, Bitcoin(100))
+
+		assertError(t, err, ErrInsufficientFunds)
+		assertBalance(t, from, Bitcoin(20))
+		assertBalance(t, to, Bitcoin(0))
+		if len(to.Ledger()) != 0 || len(from.Ledger()) != 1 {
+			t.Error("a failed transfer should not touch the ledgers")
+		}
+	})
+
+	t.Run("can't transfer to the same wallet", func(t *testing.T) {
+		wallet := &Wallet{}
+		wallet.Deposit(Bitcoin(20))
+
+		err := Transfer(wallet, wallet, Bitcoin(10))
+
+		assertError(t, err, ErrSameWallet)
+		assertBalance(t, wallet, Bitcoin(20))
+	})
+
+	t.Run("opposite transfers at the same time don't deadlock", func(t *testing.T) {
+		a, b := &Wallet{}, &Wallet{}
+		a.Deposit(Bitcoin(1000))
+		b.Deposit(Bitcoin(1000))
+
+		var wg sync.WaitGroup
+		for i := 0; i < 1000; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				Transfer(a, b, Bitcoin(1))
+			}()
+			go func() {
+				defer wg.Done()
+				Transfer(b, a, Bitcoin(1))
+			}()
+		}
+		wg.Wait()
+
+		assertBalance(t, a, Bitcoin(1000))
+		assertBalance(t, b, Bitcoin(1000))
+	})
+
+	t.Run("random concurrent transfers keep the total supply", func(t *testing.T) {
+		const walletCount = 10
+		const transfers = 5000
+		const supplyPerWallet = 100
+
+		wallets := make([]*Wallet, walletCount)
+		for i := range wallets {
+			wallets[i] = &Wallet{}
+			wallets[i].Deposit(Bitcoin(supplyPerWallet))
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(transfers)
+		for i := 0; i < transfers; i++ {
+			from, to, amount := rand.Intn(walletCount), rand.Intn(walletCount), Bitcoin(rand.Intn(50))
+			go func() {
+				defer wg.Done()
+				Transfer(wallets[from], wallets[to], amount)
+			}()
+		}
+		wg.Wait()
+
+		var total Bitcoin
+		for _, w := range wallets {
+			balance := w.Balance()
+			if balance < 0 {
+				t.Errorf("wallet went negative: %s", balance)
+			}
+			if replay := Replay(w.Ledger()); replay != balance {
+				t.Errorf("balance %s doesn't match ledger replay %s", balance, replay)
+			}
+			total += balance
+		}
+		if total != walletCount*supplyPerWallet {
+			t.Errorf("got total supply %s want %s", total, Bitcoin(walletCount*supplyPerWallet))
+		}
+	})
+}
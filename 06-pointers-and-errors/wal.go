@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"time"
+)
+
+// A write-ahead log (WAL) is a file we only ever append to. Every change is written
+// and flushed to disk with fsync before it is applied in memory, so after a crash
+// replaying the file from the start gets us back to exactly where we were.
+//
+// Each record on disk is:
+//
+//	| payload length (4 bytes) | CRC-32C of payload (4 bytes) | JSON payload |
+//
+// If the process dies half way through an append, the last record is left torn:
+// too short, or with a checksum that doesn't match. That record was never
+// acknowledged to anyone, so on startup we cut it off and carry on.
+// Damage anywhere else is different: the records after it were fsynced and
+// acknowledged, so rather than throw them away we refuse to start.
+
+var (
+	ErrCorruptWAL        = errors.New("write-ahead log is corrupt")
+	ErrWALRecordTooLarge = errors.New("write-ahead log record is too large")
+)
+
+type walOp string
+
+const (
+	opCreate   = walOp("create")
+	opDeposit  = walOp("deposit")
+	opWithdraw = walOp("withdraw")
+	opTransfer = walOp("transfer")
+	opAccrue   = walOp("accrue")
+)
+
+// walRecord is the effect of an operation, not the request for it: it has
+// already passed the wallet's checks, so replaying it must not check them again.
+type walRecord struct {
+	Seq    uint64  `json:"seq"`
+	Op     walOp   `json:"op"`
+	Wallet string  `json:"wallet"`
+	To     string  `json:"to,omitempty"`
+	Amount Bitcoin `json:"amount,omitempty"`
+	// Kind is only set for an accrual, which can be interest or a fee
+	Kind EntryKind `json:"kind,omitempty"`
+	Memo string    `json:"memo,omitempty"`
+	Time time.Time `json:"time"`
+}
+
+const walHeaderSize = 8
+
+// maxWALRecord is the biggest payload we will write, so a length field saying
+// more than this can only be damage, wherever in the log it is.
+const maxWALRecord = 1 << 16
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+type wal struct {
+	file *os.File
+}
+
+// openWAL reads every intact record from path, truncating a torn final record,
+// and leaves the file open for appending.
+func openWAL(path string) (*wal, []walRecord, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	records, good, err := readWAL(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	if good < info.Size() {
+		if err := file.Truncate(good); err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+		if err := file.Sync(); err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+	}
+
+	if _, err := file.Seek(good, io.SeekStart); err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	return &wal{file: file}, records, nil
+}
+
+// readWAL returns the records and the offset just after the last good one.
+func readWAL(r io.Reader) ([]walRecord, int64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var records []walRecord
+	var offset int64
+
+	for int64(len(data)) > offset {
+		rest := data[offset:]
+		if len(rest) < walHeaderSize {
+			// torn header
+			break
+		}
+
+		length := int64(binary.LittleEndian.Uint32(rest[0:4]))
+		checksum := binary.LittleEndian.Uint32(rest[4:8])
+		if length > maxWALRecord {
+			return nil, 0, fmt.Errorf("%w: record length %d at offset %d", ErrCorruptWAL, length, offset)
+		}
+
+		// We never write an empty record, so a length of zero is the zero filled space
+		// some filesystems leave past the last write after a crash. Like a torn payload,
+		// it marks the end of the log.
+		end := walHeaderSize + length
+		if length == 0 || int64(len(rest)) < end {
+			// An append is a single write, so a crash can only tear the last record.
+			// If a whole record turns up in what's left, it was the length that got
+			// damaged and truncating here would delete acknowledged records.
+			if containsWALRecord(rest[walHeaderSize:]) {
+				return nil, 0, fmt.Errorf("%w: bad record length at offset %d", ErrCorruptWAL, offset)
+			}
+			break
+		}
+
+		payload := rest[walHeaderSize:end]
+		if crc32.Checksum(payload, castagnoli) != checksum {
+			if int64(len(rest)) == end {
+				// the final record was only partly written
+				break
+			}
+			return nil, 0, fmt.Errorf("%w: bad checksum at offset %d", ErrCorruptWAL, offset)
+		}
+
+		var record walRecord
+		if err := json.Unmarshal(payload, &record); err != nil {
+			return nil, 0, fmt.Errorf("%w: %v at offset %d", ErrCorruptWAL, err, offset)
+		}
+		records = append(records, record)
+		offset += end
+	}
+
+	return records, offset, nil
+}
+
+// containsWALRecord reports whether a complete record with a good checksum starts
+// anywhere in data. Only the tail after a bad length is searched, which is less
+// than walHeaderSize+maxWALRecord bytes, so this stays cheap.
+func containsWALRecord(data []byte) bool {
+	for start := 0; start+walHeaderSize <= len(data); start++ {
+		rest := data[start:]
+		length := int64(binary.LittleEndian.Uint32(rest[0:4]))
+		end := walHeaderSize + length
+		// eight zero bytes would pass as an empty record, but we never write one
+		if length == 0 || length > maxWALRecord || int64(len(rest)) < end {
+			continue
+		}
+		if crc32.Checksum(rest[walHeaderSize:end], castagnoli) == binary.LittleEndian.Uint32(rest[4:8]) {
+			return true
+		}
+	}
+	return false
+}
+
+func encodeWALRecord(record walRecord) ([]byte, error) {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) > maxWALRecord {
+		return nil, fmt.Errorf("%w: %d bytes, the limit is %d", ErrWALRecordTooLarge, len(payload), maxWALRecord)
+	}
+
+	buf := bytes.Buffer{}
+	header := make([]byte, walHeaderSize)
+	binary.LittleEndian.PutUint32(header[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(header[4:8], crc32.Checksum(payload, castagnoli))
+	buf.Write(header)
+	buf.Write(payload)
+	return buf.Bytes(), nil
+}
+
+// append only returns once the record has been fsynced, so it survives a crash or power cut.
+func (w *wal) append(record walRecord) error {
+	data, err := encodeWALRecord(record)
+	if err != nil {
+		return err
+	}
+
+	// remember where we were, so a failed write can be cut back off
+	// rather than leaving half a record in the middle of the log
+	offset, err := w.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.file.Write(data); err != nil {
+		w.rewind(offset)
+		return err
+	}
+	if err := w.file.Sync(); err != nil {
+		w.rewind(offset)
+		return err
+	}
+	return nil
+}
+
+func (w *wal) rewind(offset int64) {
+	w.file.Truncate(offset)
+	w.file.Seek(offset, io.SeekStart)
+}
+
+// reset empties the log once everything in it is safely in a snapshot.
+func (w *wal) reset() error {
+	if err := w.file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return w.file.Sync()
+}
+
+func (w *wal) close() error {
+	return w.file.Close()
+}
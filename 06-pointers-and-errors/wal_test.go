@@ -0,0 +1,186 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWAL(t *testing.T) {
+	writeRecords := func(t testing.TB, path string, count int) {
+		t.Helper()
+		log, _, err := openWAL(path)
+		assertNoError(t, err)
+		for i := 1; i <= count; i++ {
+			assertNoError(t, log.append(walRecord{Seq: uint64(i), Op: opDeposit, Wallet: "alice", Amount: Bitcoin(i), Time: start}))
+		}
+		assertNoError(t, log.close())
+	}
+
+	t.Run("reads back what was appended", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "test.wal")
+		writeRecords(t, path, 3)
+
+		log, records, err := openWAL(path)
+		assertNoError(t, err)
+		defer log.close()
+
+		if len(records) != 3 || records[2].Amount != 3 || !records[2].Time.Equal(start) {
+			t.Errorf("got %+v", records)
+		}
+	})
+
+	// cutting the file short anywhere inside the last record is what a crash mid-write looks like
+	t.Run("truncates a torn final record", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "test.wal")
+		writeRecords(t, path, 2)
+		whole := fileSize(t, path)
+		writeRecords(t, path, 1)
+		full, err := os.ReadFile(path)
+		assertNoError(t, err)
+
+		for size := whole + 1; size < int64(len(full)); size++ {
+			assertNoError(t, os.WriteFile(path, full[:size], 0o644))
+
+			log, records, err := openWAL(path)
+			assertNoError(t, err)
+			log.close()
+
+			if len(records) != 2 {
+				t.Fatalf("cut at %d: got %d records want 2", size, len(records))
+			}
+			if got := fileSize(t, path); got != whole {
+				t.Fatalf("cut at %d: file is %d bytes, want it truncated to %d", size, got, whole)
+			}
+		}
+	})
+
+	// preallocated or delayed allocation filesystems can leave zeros after the last write
+	t.Run("truncates a zero filled tail", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "test.wal")
+		writeRecords(t, path, 1)
+		whole := fileSize(t, path)
+
+		for _, zeros := range []int{walHeaderSize, 4096} {
+			file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+			assertNoError(t, err)
+			_, err = file.Write(make([]byte, zeros))
+			assertNoError(t, err)
+			assertNoError(t, file.Close())
+
+			log, records, err := openWAL(path)
+			assertNoError(t, err)
+			log.close()
+
+			if len(records) != 1 {
+				t.Errorf("%d zeros: got %d records want 1", zeros, len(records))
+			}
+			if got := fileSize(t, path); got != whole {
+				t.Errorf("%d zeros: file is %d bytes, want it truncated to %d", zeros, got, whole)
+			}
+		}
+	})
+
+	t.Run("refuses to skip zeros in the middle", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "test.wal")
+		good, _ := encodeWALRecord(walRecord{Seq: 1, Op: opDeposit, Wallet: "alice", Amount: 1, Time: start})
+		data := append(make([]byte, 64), good...)
+		assertNoError(t, os.WriteFile(path, data, 0o644))
+
+		_, _, err := openWAL(path)
+		if !errors.Is(err, ErrCorruptWAL) {
+			t.Errorf("got %v want %v", err, ErrCorruptWAL)
+		}
+	})
+
+	t.Run("truncates a final record with a bad checksum", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "test.wal")
+		writeRecords(t, path, 2)
+		flipLastByte(t, path)
+
+		log, records, err := openWAL(path)
+		assertNoError(t, err)
+		defer log.close()
+
+		if len(records) != 1 {
+			t.Errorf("got %d records want 1", len(records))
+		}
+	})
+
+	t.Run("refuses to skip over corruption in the middle", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "test.wal")
+		damaged, _ := encodeWALRecord(walRecord{Seq: 1, Op: opDeposit, Wallet: "alice", Amount: 1, Time: start})
+		damaged[len(damaged)-1] ^= 0xff
+		// a good record after the damaged one means this wasn't a torn write
+		good, _ := encodeWALRecord(walRecord{Seq: 2, Op: opDeposit, Wallet: "alice", Amount: 1, Time: start})
+		assertNoError(t, os.WriteFile(path, append(damaged, good...), 0o644))
+
+		_, _, err := openWAL(path)
+		if !errors.Is(err, ErrCorruptWAL) {
+			t.Errorf("got %v want %v", err, ErrCorruptWAL)
+		}
+	})
+
+	// a length pointing past the end of the file looks like a torn record,
+	// but the acknowledged records after it show it isn't one
+	t.Run("refuses to truncate at a damaged length in the middle", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "test.wal")
+		writeRecords(t, path, 1)
+		second := fileSize(t, path)
+		writeRecords(t, path, 2)
+		full, err := os.ReadFile(path)
+		assertNoError(t, err)
+
+		cases := map[string]struct {
+			index int64
+			flip  byte
+		}{
+			"past the end of the file": {second, 0xff},
+			"past the biggest record":  {second + 3, 0x01},
+		}
+
+		for name, c := range cases {
+			t.Run(name, func(t *testing.T) {
+				damaged := append([]byte(nil), full...)
+				damaged[c.index] ^= c.flip
+				assertNoError(t, os.WriteFile(path, damaged, 0o644))
+
+				_, _, err := openWAL(path)
+				if !errors.Is(err, ErrCorruptWAL) {
+					t.Errorf("got %v want %v", err, ErrCorruptWAL)
+				}
+				if got := fileSize(t, path); got != int64(len(full)) {
+					t.Errorf("file is %d bytes, want it left at %d", got, len(full))
+				}
+			})
+		}
+	})
+
+	t.Run("will not write a record it couldn't read back", func(t *testing.T) {
+		log, _, err := openWAL(filepath.Join(t.TempDir(), "test.wal"))
+		assertNoError(t, err)
+		defer log.close()
+
+		memo := string(make([]byte, maxWALRecord))
+		err = log.append(walRecord{Seq: 1, Op: opDeposit, Wallet: "alice", Amount: 1, Memo: memo, Time: start})
+		if !errors.Is(err, ErrWALRecordTooLarge) {
+			t.Errorf("got %v want %v", err, ErrWALRecordTooLarge)
+		}
+	})
+}
+
+func fileSize(t testing.TB, path string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	assertNoError(t, err)
+	return info.Size()
+}
+
+func flipLastByte(t testing.TB, path string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	assertNoError(t, err)
+	data[len(data)-1] ^= 0xff
+	assertNoError(t, os.WriteFile(path, data, 0o644))
+}
@@ -0,0 +1,251 @@
+package dictionary
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// AutocompleteDictionary keeps a trie of its words next to the map, so it can answer
+// "which words start with this?" without looking at every word, and can suggest
+// close spellings when Search doesn't find what you typed.
+type AutocompleteDictionary struct {
+	words Dictionary
+	index *trieNode
+
+	// MaxDistance is how many edits away a word can be and still be suggested.
+	MaxDistance int
+	// MaxSuggestions caps how many suggestions a NotFoundError carries.
+	MaxSuggestions int
+}
+
+const (
+	DefaultMaxDistance    = 2
+	DefaultMaxSuggestions = 5
+)
+
+func NewAutocompleteDictionary() *AutocompleteDictionary {
+	return &AutocompleteDictionary{
+		words:          Dictionary{},
+		index:          newTrieNode(),
+		MaxDistance:    DefaultMaxDistance,
+		MaxSuggestions: DefaultMaxSuggestions,
+	}
+}
+
+// NotFoundError is what Search returns when it can't find a word. It still counts as
+// ErrNotFound for errors.Is, and errors.As gets you at the suggestions.
+type NotFoundError struct {
+	Word        string
+	Suggestions []string
+}
+
+func (e *NotFoundError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return ErrNotFound.Error()
+	}
+	return fmt.Sprintf("%v, did you mean %s?", ErrNotFound, strings.Join(e.Suggestions, ", "))
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+func (d *AutocompleteDictionary) Search(word string) (string, error) {
+	definition, err := d.words.Search(word)
+	if err == ErrNotFound {
+		return "", &NotFoundError{Word: word, Suggestions: d.Suggest(word, d.MaxDistance, d.MaxSuggestions)}
+	}
+	return definition, err
+}
+
+func (d *AutocompleteDictionary) Add(word, definition string) error {
+	if err := d.words.Add(word, definition); err != nil {
+		return err
+	}
+	d.index.insert(word)
+	return nil
+}
+
+// Update can't change which words there are, so the trie doesn't need to know about it.
+func (d *AutocompleteDictionary) Update(word, definition string) error {
+	return d.words.Update(word, definition)
+}
+
+func (d *AutocompleteDictionary) Delete(word string) error {
+	if err := d.words.Delete(word); err != nil {
+		return err
+	}
+	d.index.remove(word)
+	return nil
+}
+
+// Complete returns up to limit words starting with prefix, in alphabetical order.
+// A limit of 0 or less means no limit.
+func (d *AutocompleteDictionary) Complete(prefix string, limit int) []string {
+	node := d.index
+	for _, r := range prefix {
+		node = node.children[r]
+		if node == nil {
+			return nil
+		}
+	}
+
+	var words []string
+	node.walk([]rune(prefix), func(word string) bool {
+		words = append(words, word)
+		return limit <= 0 || len(words) < limit
+	})
+	return words
+}
+
+// Suggest returns up to limit words within maxDistance edits of word, closest first
+// and alphabetically among words that are equally close.
+//
+// An edit is inserting, deleting or substituting a letter, or swapping two neighbouring ones,
+// which is the Damerau-Levenshtein distance (strictly, the optimal string alignment variant,
+// where a swapped pair can't be edited again). Swaps count as one edit because they are
+// one of the most common typos, and plain Levenshtein would put "teh" two edits from "the".
+func (d *AutocompleteDictionary) Suggest(word string, maxDistance, limit int) []string {
+	found := d.index.withinDistance(word, maxDistance)
+	slices.SortStableFunc(found, func(a, b suggestion) int { return a.distance - b.distance })
+
+	var words []string
+	for _, s := range found {
+		if s.word == word {
+			continue
+		}
+		if limit > 0 && len(words) == limit {
+			break
+		}
+		words = append(words, s.word)
+	}
+	return words
+}
+
+type suggestion struct {
+	word     string
+	distance int
+}
+
+// withinDistance returns every word in the trie within maxDistance edits of word,
+// including word itself if it's there, in alphabetical order.
+func (n *trieNode) withinDistance(word string, maxDistance int) []suggestion {
+	target := []rune(word)
+	var found []suggestion
+
+	// Rather than work out the distance to every word separately, we walk the trie building
+	// one row of the edit distance table per letter. Words that share a prefix share rows,
+	// and once every entry in a row is too far away nothing below that node can be close enough.
+	first := make([]int, len(target)+1)
+	for i := range first {
+		first[i] = i
+	}
+
+	// the root is the empty word, which the walk below never looks at
+	if n.terminal && len(target) <= maxDistance {
+		found = append(found, suggestion{"", len(target)})
+	}
+
+	var search func(node *trieNode, path []rune, previous, beforePrevious []int)
+	search = func(node *trieNode, path []rune, previous, beforePrevious []int) {
+		for _, r := range node.sortedKeys() {
+			child := node.children[r]
+			row := make([]int, len(target)+1)
+			row[0] = previous[0] + 1
+
+			for i := 1; i <= len(target); i++ {
+				cost := 1
+				if target[i-1] == r {
+					cost = 0
+				}
+				row[i] = min(row[i-1]+1, previous[i]+1, previous[i-1]+cost)
+
+				if i > 1 && len(path) > 0 && target[i-1] == path[len(path)-1] && target[i-2] == r {
+					row[i] = min(row[i], beforePrevious[i-2]+1)
+				}
+			}
+
+			childPath := append(path, r)
+			if child.terminal && row[len(target)] <= maxDistance {
+				found = append(found, suggestion{string(childPath), row[len(target)]})
+			}
+
+			// a swap can reach back two rows, so the row before this one has to be ruled out as well
+			if slices.Min(row) <= maxDistance || slices.Min(previous)+1 <= maxDistance {
+				search(child, slices.Clip(childPath), row, previous)
+			}
+		}
+	}
+	search(n, nil, first, nil)
+
+	return found
+}
+
+// trieNode is one letter of the trie. The path from the root spells out a word,
+// and terminal says whether a word ends here or it's only part of a longer one.
+type trieNode struct {
+	children map[rune]*trieNode
+	terminal bool
+}
+
+func newTrieNode() *trieNode {
+	return &trieNode{children: map[rune]*trieNode{}}
+}
+
+func (n *trieNode) insert(word string) {
+	for _, r := range word {
+		child, ok := n.children[r]
+		if !ok {
+			child = newTrieNode()
+			n.children[r] = child
+		}
+		n = child
+	}
+	n.terminal = true
+}
+
+// remove unmarks the word and prunes any branches that no longer lead to a word,
+// so the trie doesn't keep growing as words come and go.
+func (n *trieNode) remove(word string) {
+	letters := []rune(word)
+	path := []*trieNode{n}
+	for _, r := range letters {
+		n = n.children[r]
+		if n == nil {
+			return
+		}
+		path = append(path, n)
+	}
+	n.terminal = false
+
+	for i := len(letters); i > 0; i-- {
+		node := path[i]
+		if node.terminal || len(node.children) > 0 {
+			return
+		}
+		delete(path[i-1].children, letters[i-1])
+	}
+}
+
+// walk calls visit with every word below n in alphabetical order, until visit returns false.
+func (n *trieNode) walk(prefix []rune, visit func(string) bool) bool {
+	if n.terminal && !visit(string(prefix)) {
+		return false
+	}
+	for _, r := range n.sortedKeys() {
+		if !n.children[r].walk(append(prefix, r), visit) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n *trieNode) sortedKeys() []rune {
+	keys := make([]rune, 0, len(n.children))
+	for r := range n.children {
+		keys = append(keys, r)
+	}
+	slices.Sort(keys)
+	return keys
+}
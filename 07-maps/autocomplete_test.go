@@ -0,0 +1,184 @@
+package dictionary
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	dictionary := newAutocompleteDictionary("car", "card", "care", "cart", "cat", "dog", "café")
+
+	t.Run("words starting with a prefix", func(t *testing.T) {
+		assertWords(t, dictionary.Complete("car", 0), "car", "card", "care", "cart")
+		assertWords(t, dictionary.Complete("ca", 0), "café", "car", "card", "care", "cart", "cat")
+		assertWords(t, dictionary.Complete("caf", 0), "café")
+		assertWords(t, dictionary.Complete("x", 0))
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		assertWords(t, dictionary.Complete("car", 2), "car", "card")
+	})
+
+	t.Run("empty prefix lists everything", func(t *testing.T) {
+		if got := dictionary.Complete("", 0); len(got) != 7 {
+			t.Errorf("got %v want all 7 words", got)
+		}
+	})
+}
+
+func TestAutocompleteStaysConsistent(t *testing.T) {
+	dictionary := newAutocompleteDictionary("car", "card")
+
+	assertError(t, dictionary.Add("care", "look after"), nil)
+	assertWords(t, dictionary.Complete("car", 0), "car", "card", "care")
+
+	assertError(t, dictionary.Add("car", "again"), ErrWordExists)
+	assertError(t, dictionary.Update("cart", "trolley"), ErrWordDoesNotExist)
+	assertWords(t, dictionary.Complete("car", 0), "car", "card", "care")
+
+	dictionary.Delete("car")
+	assertWords(t, dictionary.Complete("car", 0), "card", "care")
+	assertWords(t, dictionary.Suggest("car", 1, 0), "card", "care")
+
+	dictionary.Delete("card")
+	dictionary.Delete("care")
+	assertWords(t, dictionary.Complete("", 0))
+	if len(dictionary.index.children) != 0 {
+		t.Errorf("deleting every word should empty the trie, got %v", dictionary.index.children)
+	}
+
+	assertError(t, dictionary.Delete("never added"), ErrWordDoesNotExist)
+}
+
+func TestSuggest(t *testing.T) {
+	dictionary := newAutocompleteDictionary("the", "tea", "then", "them", "apple", "apply", "ample", "maple")
+
+	cases := []struct {
+		word        string
+		maxDistance int
+		want        []string
+	}{
+		{"teh", 1, []string{"tea", "the"}},
+		{"teh", 2, []string{"tea", "the", "them", "then"}},
+		{"aple", 1, []string{"ample", "apple", "maple"}},
+		{"appel", 1, []string{"apple"}},
+		{"the", 1, []string{"them", "then"}},
+		{"zzz", 2, nil},
+	}
+
+	for _, c := range cases {
+		assertWords(t, dictionary.Suggest(c.word, c.maxDistance, 0), c.want...)
+	}
+
+	t.Run("limit", func(t *testing.T) {
+		assertWords(t, dictionary.Suggest("tehm", 2, 2), "them", "tea")
+	})
+
+	t.Run("agrees with working the distance out word by word", func(t *testing.T) {
+		words := []string{"kitten", "sitting", "mitten", "bitten", "knitting", "ca", "abc", "acb", "cab", "a", "", "café", "cafe"}
+		dictionary := newAutocompleteDictionary(words...)
+
+		for _, query := range append(words, "kiten", "sittin", "ac", "bca", "caef") {
+			for maxDistance := range 4 {
+				var want []string
+				for _, word := range words {
+					if word != query && editDistance(query, word) <= maxDistance {
+						want = append(want, word)
+					}
+				}
+
+				got := dictionary.Suggest(query, maxDistance, 0)
+				slices.Sort(got)
+				slices.Sort(want)
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("Suggest(%q, %d) got %v want %v", query, maxDistance, got, want)
+				}
+			}
+		}
+	})
+}
+
+func TestSearchSuggestions(t *testing.T) {
+	dictionary := newAutocompleteDictionary("the", "then", "apple")
+
+	t.Run("not found still matches ErrNotFound", func(t *testing.T) {
+		_, err := dictionary.Search("thn")
+
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("got %v want %v", err, ErrNotFound)
+		}
+		assertStrings(t, err.Error(), "could not find the word you were looking for, did you mean the, then?")
+	})
+
+	t.Run("suggestions are available with errors.As", func(t *testing.T) {
+		_, err := dictionary.Search("aple")
+
+		var notFound *NotFoundError
+		if !errors.As(err, &notFound) {
+			t.Fatalf("got %T want *NotFoundError", err)
+		}
+		assertStrings(t, notFound.Word, "aple")
+		assertWords(t, notFound.Suggestions, "apple")
+	})
+
+	t.Run("nothing close", func(t *testing.T) {
+		_, err := dictionary.Search("zebra")
+
+		assertStrings(t, err.Error(), ErrNotFound.Error())
+	})
+
+	t.Run("found", func(t *testing.T) {
+		definition, err := dictionary.Search("then")
+
+		assertError(t, err, nil)
+		assertStrings(t, definition, "definition of then")
+	})
+}
+
+func newAutocompleteDictionary(words ...string) *AutocompleteDictionary {
+	dictionary := NewAutocompleteDictionary()
+	for _, word := range words {
+		dictionary.Add(word, "definition of "+word)
+	}
+	return dictionary
+}
+
+func assertWords(t testing.TB, got []string, want ...string) {
+	t.Helper()
+
+	if len(got) == 0 && len(want) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+// editDistance is the textbook optimal string alignment distance, to check the trie walk against.
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(s)][len(t)]
+}
@@ -0,0 +1,119 @@
+// dict is the dictionary on the command line.
+//
+//	dict                             an interactive prompt, with tab completion
+//	dict -script fixtures.txt        run the commands in a file and stop at the first error
+//	dict serve -addr :5000           serve the HTTP API
+//	dict spell -words words.json < README.md
+//
+// Type help at the prompt for the commands.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+
+	"golang.org/x/term"
+
+	dictionary "maps_dictionary_chapter"
+)
+
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "serve":
+			serve(os.Args[2:])
+			return
+		case "spell":
+			spell(os.Args[2:])
+			return
+		}
+	}
+
+	script := flag.String("script", "", "run the commands in this file instead of prompting")
+	author := flag.String("author", os.Getenv("USER"), "name to put on changes in the history")
+	flag.Parse()
+
+	words := dictionary.NewVersionedDictionary(nil)
+
+	if *script != "" {
+		file, err := os.Open(*script)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		runScript(dictionary.NewREPL(words, *author, os.Stdout, os.Stderr), file, *script)
+		return
+	}
+
+	// piping commands in works like a script
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		runScript(dictionary.NewREPL(words, *author, os.Stdout, os.Stderr), os.Stdin, "stdin")
+		return
+	}
+
+	if err := interactive(words, *author); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// interactive puts the terminal in raw mode so x/term can handle every key press itself,
+// which is what lets tab complete words instead of inserting a tab.
+func interactive(words *dictionary.VersionedDictionary, author string) error {
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	terminal := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, "dict> ")
+
+	// in raw mode "\n" no longer goes back to the start of the line, the terminal's writer sorts that out
+	repl := dictionary.NewREPL(words, author, terminal, terminal)
+	repl.Color = useColor(os.Stdout)
+	terminal.AutoCompleteCallback = repl.Complete
+
+	return repl.Run(terminal.ReadLine)
+}
+
+func runScript(repl *dictionary.REPL, script io.Reader, name string) {
+	repl.Color = useColor(os.Stderr)
+	if err := repl.RunScript(script, name); err != nil {
+		repl.PrintError(err)
+		os.Exit(1)
+	}
+}
+
+// useColor follows https://no-color.org and only colours output going to a terminal.
+func useColor(f *os.File) bool {
+	return os.Getenv("NO_COLOR") == "" && term.IsTerminal(int(f.Fd()))
+}
+
+func serve(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", ":5000", "address to serve the dictionary API on")
+	flags.Parse(args)
+
+	server := dictionary.NewDictionaryServer(dictionary.NewConcurrentDictionary())
+	log.Fatal(http.ListenAndServe(*addr, server))
+}
+
+func spell(args []string) {
+	err := dictionary.RunSpellCLI(args, os.Stdin, os.Stdout, os.Stderr)
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+	case errors.Is(err, dictionary.ErrMisspelled):
+		os.Exit(1)
+	default:
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+}
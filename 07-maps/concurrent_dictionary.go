@@ -0,0 +1,91 @@
+package dictionary
+
+import (
+	"hash/maphash"
+	"slices"
+	"sync"
+)
+
+// A Dictionary is a plain map, and Go's maps aren't safe for concurrent use:
+// two goroutines writing at once crash the program with "concurrent map writes".
+//
+// The simple fix is one sync.RWMutex around the whole map, but then every writer
+// waits for every other writer, even when they're working on different words.
+// ConcurrentDictionary splits the words into shards, each a Dictionary with its own lock,
+// and picks the shard by hashing the word. Two goroutines only wait for each other
+// when their words land in the same shard.
+type ConcurrentDictionary struct {
+	seed   maphash.Seed
+	shards []*dictionaryShard
+}
+
+type dictionaryShard struct {
+	mu    sync.RWMutex
+	words Dictionary
+}
+
+const defaultShardCount = 32
+
+func NewConcurrentDictionary() *ConcurrentDictionary {
+	return NewConcurrentDictionaryWithShards(defaultShardCount)
+}
+
+// NewConcurrentDictionaryWithShards is for when you know better than the default, anything below one is treated as one.
+func NewConcurrentDictionaryWithShards(count int) *ConcurrentDictionary {
+	d := &ConcurrentDictionary{seed: maphash.MakeSeed(), shards: make([]*dictionaryShard, max(count, 1))}
+	for i := range d.shards {
+		d.shards[i] = &dictionaryShard{words: Dictionary{}}
+	}
+	return d
+}
+
+func (d *ConcurrentDictionary) shard(word string) *dictionaryShard {
+	return d.shards[maphash.String(d.seed, word)%uint64(len(d.shards))]
+}
+
+// Each shard is a Dictionary, so once we hold the right lock we reuse its methods
+// and get exactly the same behaviour and errors.
+
+func (d *ConcurrentDictionary) Search(word string) (string, error) {
+	shard := d.shard(word)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	return shard.words.Search(word)
+}
+
+func (d *ConcurrentDictionary) Add(word, definition string) error {
+	shard := d.shard(word)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	return shard.words.Add(word, definition)
+}
+
+func (d *ConcurrentDictionary) Update(word, definition string) error {
+	shard := d.shard(word)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	return shard.words.Update(word, definition)
+}
+
+func (d *ConcurrentDictionary) Delete(word string) error {
+	shard := d.shard(word)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	return shard.words.Delete(word)
+}
+
+// Words locks one shard at a time, so words added or deleted while it runs may or may not be included.
+func (d *ConcurrentDictionary) Words() []string {
+	var words []string
+	for _, shard := range d.shards {
+		shard.mu.RLock()
+		words = append(words, shard.words.Words()...)
+		shard.mu.RUnlock()
+	}
+	slices.Sort(words)
+	return words
+}
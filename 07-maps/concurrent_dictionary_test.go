@@ -0,0 +1,145 @@
+package dictionary
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestConcurrentDictionary(t *testing.T) {
+	t.Run("behaves like a Dictionary", func(t *testing.T) {
+		dictionary := NewConcurrentDictionary()
+
+		assertError(t, dictionary.Add("test", "this is just a test"), nil)
+		assertError(t, dictionary.Add("test", "new test"), ErrWordExists)
+		assertConcurrentDefinition(t, dictionary, "test", "this is just a test")
+
+		assertError(t, dictionary.Update("test", "new definition"), nil)
+		assertError(t, dictionary.Update("unknown", "definition"), ErrWordDoesNotExist)
+		assertConcurrentDefinition(t, dictionary, "test", "new definition")
+
+		assertError(t, dictionary.Delete("test"), nil)
+		assertError(t, dictionary.Delete("test"), ErrWordDoesNotExist)
+		_, err := dictionary.Search("test")
+		assertError(t, err, ErrNotFound)
+	})
+
+	t.Run("works with a single shard", func(t *testing.T) {
+		dictionary := NewConcurrentDictionaryWithShards(0)
+
+		dictionary.Add("a", "first")
+		dictionary.Add("b", "second")
+
+		assertConcurrentDefinition(t, dictionary, "a", "first")
+		assertConcurrentDefinition(t, dictionary, "b", "second")
+	})
+
+	// run with go test -race, a plain Dictionary would crash here with "concurrent map writes"
+	t.Run("it runs safely concurrently", func(t *testing.T) {
+		dictionary := NewConcurrentDictionary()
+		const goroutines = 50
+
+		var wg sync.WaitGroup
+		wg.Add(goroutines)
+		for i := 0; i < goroutines; i++ {
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					word := fmt.Sprintf("word-%d", j)
+					// everyone races to add the same words, exactly one of them wins each
+					if dictionary.Add(word, "first") != nil {
+						dictionary.Update(word, fmt.Sprintf("updated by %d", i))
+					}
+					dictionary.Search(word)
+				}
+			}()
+		}
+		wg.Wait()
+
+		for j := 0; j < 100; j++ {
+			if _, err := dictionary.Search(fmt.Sprintf("word-%d", j)); err != nil {
+				t.Errorf("word-%d: %v", j, err)
+			}
+		}
+	})
+}
+
+func assertConcurrentDefinition(t testing.TB, dictionary *ConcurrentDictionary, word, definition string) {
+	t.Helper()
+
+	got, err := dictionary.Search(word)
+	if err != nil {
+		t.Fatal("should find added word:", err)
+	}
+	assertStrings(t, got, definition)
+}
+
+// RWMutexDictionary is the obvious alternative, one lock around one map,
+// kept here so the benchmarks have something to compare against.
+type RWMutexDictionary struct {
+	mu    sync.RWMutex
+	words Dictionary
+}
+
+func (d *RWMutexDictionary) Search(word string) (string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.words.Search(word)
+}
+
+func (d *RWMutexDictionary) Update(word, definition string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.words.Update(word, definition)
+}
+
+type benchmarkedDictionary interface {
+	Search(word string) (string, error)
+	Update(word, definition string) error
+}
+
+// go test -bench=. -cpu=1,4,16
+// mostly reads with one write in every writeEvery operations, spread across many words
+func benchmarkDictionary(b *testing.B, dictionary benchmarkedDictionary, writeEvery int) {
+	words := make([]string, 1024)
+	for i := range words {
+		words[i] = fmt.Sprintf("word-%d", i)
+	}
+
+	var next atomic.Int64
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		// start each goroutine somewhere different so they aren't all after the same word at once
+		i := int(next.Add(1) * 7919)
+		for pb.Next() {
+			word := words[i%len(words)]
+			if i%writeEvery == 0 {
+				dictionary.Update(word, "updated")
+			} else {
+				dictionary.Search(word)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkDictionaries(b *testing.B) {
+	for _, writeEvery := range []int{2, 10, 100} {
+		sharded := NewConcurrentDictionary()
+		single := &RWMutexDictionary{words: Dictionary{}}
+		for i := 0; i < 1024; i++ {
+			word := fmt.Sprintf("word-%d", i)
+			sharded.Add(word, "definition")
+			single.words.Add(word, "definition")
+		}
+
+		b.Run(fmt.Sprintf("sharded/1 write in %d", writeEvery), func(b *testing.B) {
+			benchmarkDictionary(b, sharded, writeEvery)
+		})
+		b.Run(fmt.Sprintf("single RWMutex/1 write in %d", writeEvery), func(b *testing.B) {
+			benchmarkDictionary(b, single, writeEvery)
+		})
+	}
+}
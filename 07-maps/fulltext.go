@@ -0,0 +1,267 @@
+package dictionary
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Search only finds a word when you already know it. IndexedDictionary also keeps an inverted
+// index of the definitions, a map from every term to the words whose definitions use it,
+// so SearchDefinitions can find words by what they mean.
+//
+// Definitions are split into lower case terms, common words like "the" and "of" are dropped
+// because nearly every definition has them, and each term is stemmed so "sorting" finds "sorted".
+// The index is updated as words are added, updated and deleted, so it never has to be rebuilt.
+type IndexedDictionary struct {
+	words Dictionary
+	// postings maps a term to the words using it, and where in the definition it appears
+	postings map[string]map[string][]int
+	// lengths is how many terms each definition has, which BM25 needs
+	lengths     map[string]int
+	totalLength int
+}
+
+func NewIndexedDictionary() *IndexedDictionary {
+	return &IndexedDictionary{
+		words:    Dictionary{},
+		postings: map[string]map[string][]int{},
+		lengths:  map[string]int{},
+	}
+}
+
+func (d *IndexedDictionary) Search(word string) (string, error) {
+	return d.words.Search(word)
+}
+
+func (d *IndexedDictionary) Add(word, definition string) error {
+	if err := d.words.Add(word, definition); err != nil {
+		return err
+	}
+	d.index(word, definition)
+	return nil
+}
+
+func (d *IndexedDictionary) Update(word, definition string) error {
+	old, err := d.words.Search(word)
+	if err != nil {
+		return ErrWordDoesNotExist
+	}
+	if err := d.words.Update(word, definition); err != nil {
+		return err
+	}
+	d.unindex(word, old)
+	d.index(word, definition)
+	return nil
+}
+
+func (d *IndexedDictionary) Delete(word string) error {
+	old, err := d.words.Search(word)
+	if err != nil {
+		return ErrWordDoesNotExist
+	}
+	if err := d.words.Delete(word); err != nil {
+		return err
+	}
+	d.unindex(word, old)
+	return nil
+}
+
+func (d *IndexedDictionary) Words() []string {
+	return d.words.Words()
+}
+
+type SearchResult struct {
+	Word       string  `json:"word"`
+	Definition string  `json:"definition"`
+	Score      float64 `json:"score"`
+}
+
+// SearchDefinitions returns up to limit words whose definitions best match the query, best first.
+// A limit of 0 or less means no limit.
+//
+// Put words in double quotes to search for a phrase: `"key value" pairs` only finds
+// definitions with "key" followed by "value", and ranks those that also mention pairs higher.
+// Once there's a phrase, words outside quotes only change the ranking. Without one,
+// a definition has to match at least one of the words to be returned.
+func (d *IndexedDictionary) SearchDefinitions(query string, limit int) []SearchResult {
+	phrases, terms := parseQuery(query)
+
+	// phrases are required, so when there are any they alone decide what is returned
+	var candidates map[string]bool
+	if len(phrases) > 0 {
+		candidates = d.matchPhrases(phrases)
+	} else {
+		candidates = map[string]bool{}
+		for _, term := range terms {
+			for word := range d.postings[term] {
+				candidates[word] = true
+			}
+		}
+	}
+
+	var scoring []string
+	scoring = append(scoring, terms...)
+	for _, phrase := range phrases {
+		for _, token := range phrase {
+			scoring = append(scoring, token.term)
+		}
+	}
+
+	results := make([]SearchResult, 0, len(candidates))
+	for word := range candidates {
+		results = append(results, SearchResult{Word: word, Definition: d.words[word], Score: d.score(word, scoring)})
+	}
+	slices.SortFunc(results, func(a, b SearchResult) int {
+		if a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Word, b.Word)
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// BM25 parameters, the usual defaults. k1 controls how quickly repeating a term stops adding
+// to the score, b how much longer definitions are penalised for having more words to match.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// score is the Okapi BM25 ranking function. A term counts for more the rarer it is across all
+// definitions (its inverse document frequency) and the more often it appears in this one,
+// with diminishing returns, relative to how long the definition is.
+func (d *IndexedDictionary) score(word string, terms []string) float64 {
+	documents := float64(len(d.lengths))
+	averageLength := float64(d.totalLength) / documents
+	length := float64(d.lengths[word])
+
+	score := 0.0
+	for _, term := range terms {
+		frequency := float64(len(d.postings[term][word]))
+		if frequency == 0 {
+			continue
+		}
+		using := float64(len(d.postings[term]))
+		idf := math.Log(1 + (documents-using+0.5)/(using+0.5))
+		score += idf * frequency * (bm25K1 + 1) / (frequency + bm25K1*(1-bm25B+bm25B*length/averageLength))
+	}
+	return score
+}
+
+// matchPhrases returns the words whose definitions contain every phrase.
+func (d *IndexedDictionary) matchPhrases(phrases [][]token) map[string]bool {
+	matches := map[string]bool{}
+	for word := range d.postings[phrases[0][0].term] {
+		matches[word] = true
+	}
+	for _, phrase := range phrases {
+		for word := range matches {
+			if !d.containsPhrase(word, phrase) {
+				delete(matches, word)
+			}
+		}
+	}
+	return matches
+}
+
+// containsPhrase checks the phrase's terms appear at the same distances from each other in the
+// definition as in the phrase. Stop words still take up a position, so "out of date" matches
+// "out of date" but not "out date".
+func (d *IndexedDictionary) containsPhrase(word string, phrase []token) bool {
+	first := phrase[0]
+	for _, start := range d.postings[first.term][word] {
+		found := true
+		for _, next := range phrase[1:] {
+			if !slices.Contains(d.postings[next.term][word], start+next.position-first.position) {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *IndexedDictionary) index(word, definition string) {
+	tokens := tokenize(definition)
+	for _, token := range tokens {
+		if d.postings[token.term] == nil {
+			d.postings[token.term] = map[string][]int{}
+		}
+		d.postings[token.term][word] = append(d.postings[token.term][word], token.position)
+	}
+	d.lengths[word] = len(tokens)
+	d.totalLength += len(tokens)
+}
+
+func (d *IndexedDictionary) unindex(word, definition string) {
+	for _, token := range tokenize(definition) {
+		delete(d.postings[token.term], word)
+		// drop terms nobody uses any more, so the index doesn't grow forever
+		if len(d.postings[token.term]) == 0 {
+			delete(d.postings, token.term)
+		}
+	}
+	d.totalLength -= d.lengths[word]
+	delete(d.lengths, word)
+}
+
+type token struct {
+	term     string
+	position int
+}
+
+// tokenize splits text into stemmed, lower case terms and drops stop words.
+// Each term remembers its position in the text, counting the dropped words too.
+func tokenize(text string) []token {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	var tokens []token
+	for position, word := range words {
+		if stopWords[word] {
+			continue
+		}
+		tokens = append(tokens, token{term: Stem(word), position: position})
+	}
+	return tokens
+}
+
+// parseQuery splits a query into phrases, from inside double quotes, and everything else.
+// An unclosed quote runs to the end of the query.
+func parseQuery(query string) (phrases [][]token, terms []string) {
+	for i, part := range strings.Split(query, `"`) {
+		tokens := tokenize(part)
+		// every other part is inside quotes
+		if i%2 == 1 && len(tokens) > 0 {
+			phrases = append(phrases, tokens)
+			continue
+		}
+		for _, token := range tokens {
+			terms = append(terms, token.term)
+		}
+	}
+	return phrases, terms
+}
+
+// stopWords are too common to tell one definition from another.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"but": true, "by": true, "for": true, "from": true, "has": true, "have": true, "if": true,
+	"in": true, "into": true, "is": true, "it": true, "its": true, "of": true, "on": true,
+	"or": true, "so": true, "such": true, "that": true, "the": true, "their": true, "then": true,
+	"there": true, "these": true, "they": true, "this": true, "to": true, "was": true,
+	"which": true, "will": true, "with": true,
+}
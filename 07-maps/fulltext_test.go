@@ -0,0 +1,129 @@
+package dictionary
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSearchDefinitions(t *testing.T) {
+	newDictionary := func() *IndexedDictionary {
+		dictionary := NewIndexedDictionary()
+		dictionary.Add("map", "an unordered collection of key value pairs")
+		dictionary.Add("slice", "a view of part of an array that can grow")
+		dictionary.Add("array", "a fixed size sequence of values")
+		dictionary.Add("sort", "put values in order, like sorting an array of numbers")
+		dictionary.Add("stale", "out of date")
+		dictionary.Add("update", "bring up to date, out with the old")
+		return dictionary
+	}
+
+	t.Run("finds words by their definition", func(t *testing.T) {
+		dictionary := newDictionary()
+
+		assertResultWords(t, dictionary.SearchDefinitions("pairs", 0), "map")
+		assertResultWords(t, dictionary.SearchDefinitions("nothing matches", 0))
+	})
+
+	t.Run("stemming matches other forms of a word", func(t *testing.T) {
+		dictionary := newDictionary()
+
+		assertResultWords(t, dictionary.SearchDefinitions("sorted", 0), "sort")
+		assertResultWords(t, dictionary.SearchDefinitions("growing", 0), "slice")
+		assertResultWords(t, dictionary.SearchDefinitions("Collections", 0), "map")
+	})
+
+	t.Run("stop words are ignored", func(t *testing.T) {
+		dictionary := newDictionary()
+
+		assertResultWords(t, dictionary.SearchDefinitions("the of an", 0))
+	})
+
+	t.Run("rarer and more frequent terms rank higher", func(t *testing.T) {
+		dictionary := newDictionary()
+
+		// sort mentions both. Two definitions mention array and three mention values, so slice
+		// comes next, and array beats map because its definition is shorter
+		assertResultWords(t, dictionary.SearchDefinitions("array values", 0), "sort", "slice", "array", "map")
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		dictionary := newDictionary()
+
+		assertResultWords(t, dictionary.SearchDefinitions("array values", 2), "sort", "slice")
+	})
+
+	t.Run("phrases", func(t *testing.T) {
+		dictionary := newDictionary()
+
+		assertResultWords(t, dictionary.SearchDefinitions(`"out of date"`, 0), "stale")
+		assertResultWords(t, dictionary.SearchDefinitions(`"key value"`, 0), "map")
+		assertResultWords(t, dictionary.SearchDefinitions(`"value key"`, 0))
+		assertResultWords(t, dictionary.SearchDefinitions(`"up to date" old`, 0), "update")
+		assertResultWords(t, dictionary.SearchDefinitions(`"up to date" "key value"`, 0))
+		assertResultWords(t, dictionary.SearchDefinitions(`"the of"`, 0))
+		assertResultWords(t, dictionary.SearchDefinitions(`"fixed size`, 0), "array")
+	})
+
+	t.Run("words next to a phrase only change the ranking", func(t *testing.T) {
+		dictionary := newDictionary()
+
+		assertResultWords(t, dictionary.SearchDefinitions(`"key value" unicorns`, 0), "map")
+		assertResultWords(t, dictionary.SearchDefinitions(`"date"`, 0), "stale", "update")
+		assertResultWords(t, dictionary.SearchDefinitions(`"date" old`, 0), "update", "stale")
+	})
+
+	t.Run("results carry the definition and a score", func(t *testing.T) {
+		dictionary := newDictionary()
+
+		results := dictionary.SearchDefinitions("pairs", 0)
+
+		assertStrings(t, results[0].Definition, "an unordered collection of key value pairs")
+		if results[0].Score <= 0 {
+			t.Errorf("got score %v want more than 0", results[0].Score)
+		}
+	})
+}
+
+func TestIndexStaysUpToDate(t *testing.T) {
+	dictionary := NewIndexedDictionary()
+	dictionary.Add("map", "an unordered collection of key value pairs")
+
+	assertError(t, dictionary.Add("map", "again"), ErrWordExists)
+	assertResultWords(t, dictionary.SearchDefinitions("again", 0))
+
+	assertError(t, dictionary.Update("map", "a hash table"), nil)
+	assertResultWords(t, dictionary.SearchDefinitions("pairs", 0))
+	assertResultWords(t, dictionary.SearchDefinitions("hash", 0), "map")
+
+	assertError(t, dictionary.Update("unknown", "definition"), ErrWordDoesNotExist)
+	assertResultWords(t, dictionary.SearchDefinitions("definition", 0))
+
+	assertError(t, dictionary.Delete("map"), nil)
+	assertError(t, dictionary.Delete("map"), ErrWordDoesNotExist)
+	assertResultWords(t, dictionary.SearchDefinitions("hash", 0))
+
+	if len(dictionary.postings) != 0 || len(dictionary.lengths) != 0 || dictionary.totalLength != 0 {
+		t.Errorf("deleting every word should empty the index, got %v %v %d", dictionary.postings, dictionary.lengths, dictionary.totalLength)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	got := tokenize("The quick, brown fox's jumping!")
+	want := []token{{"quick", 1}, {"brown", 2}, {"fox", 3}, {"s", 4}, {"jump", 5}}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func assertResultWords(t testing.TB, results []SearchResult, want ...string) {
+	t.Helper()
+
+	var got []string
+	for _, result := range results {
+		got = append(got, result.Word)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v (%+v)", got, want, results)
+	}
+}
@@ -0,0 +1,264 @@
+package dictionary
+
+import (
+	"slices"
+)
+
+// A Dictionary maps a word to one definition, which is fine until a word means more than one thing.
+// A Glossary keeps a whole Entry per word instead: any number of senses, each with its own
+// part of speech, examples, synonyms and antonyms, plus links to related entries.
+//
+// Glossary still has Search, Add, Update and Delete with the same signatures and errors as Dictionary,
+// which work on an entry's first sense, so code written against the string API keeps working.
+// The one difference is that every sense needs a definition, so Add and Update refuse an empty one
+// with ErrEmptyDefinition where a Dictionary would store it. An entry put straight into the map with
+// no senses has no first sense either: Search doesn't find it, Dictionary leaves it out and Update
+// gives it one.
+
+type PartOfSpeech string
+
+const (
+	Noun         = PartOfSpeech("noun")
+	Verb         = PartOfSpeech("verb")
+	Adjective    = PartOfSpeech("adjective")
+	Adverb       = PartOfSpeech("adverb")
+	Pronoun      = PartOfSpeech("pronoun")
+	Preposition  = PartOfSpeech("preposition")
+	Conjunction  = PartOfSpeech("conjunction")
+	Interjection = PartOfSpeech("interjection")
+)
+
+// Sense is one meaning of a word.
+type Sense struct {
+	PartOfSpeech PartOfSpeech `json:"partOfSpeech,omitempty"`
+	Definition   string       `json:"definition"`
+	Examples     []string     `json:"examples,omitempty"`
+	Synonyms     []string     `json:"synonyms,omitempty"`
+	Antonyms     []string     `json:"antonyms,omitempty"`
+}
+
+type Entry struct {
+	Word   string  `json:"word"`
+	Senses []Sense `json:"senses"`
+	// SeeAlso lists the words this entry is linked to. Links always go both ways.
+	SeeAlso []string `json:"seeAlso,omitempty"`
+}
+
+const (
+	ErrNoSenses         = DictionaryErr("an entry needs at least one sense")
+	ErrEmptyDefinition  = DictionaryErr("a sense needs a definition")
+	ErrSenseNotFound    = DictionaryErr("the word has no sense with that number")
+	ErrLastSense        = DictionaryErr("cannot remove the only sense of a word, delete the word instead")
+	ErrSelfLink         = DictionaryErr("cannot link a word to itself")
+	ErrLinkDoesNotExist = DictionaryErr("the words are not linked")
+)
+
+type Glossary map[string]Entry
+
+// Search returns the definition of the first sense, which is how a Glossary looks through the Dictionary API.
+func (g Glossary) Search(word string) (string, error) {
+	entry, ok := g[word]
+	if !ok || len(entry.Senses) == 0 {
+		return "", ErrNotFound
+	}
+	return entry.Senses[0].Definition, nil
+}
+
+// Add makes an entry with a single sense that has no part of speech.
+func (g Glossary) Add(word, definition string) error {
+	return g.AddEntry(Entry{Word: word, Senses: []Sense{{Definition: definition}}})
+}
+
+// Update replaces the definition of the first sense and leaves everything else alone.
+func (g Glossary) Update(word, definition string) error {
+	entry, ok := g[word]
+	if !ok {
+		return ErrWordDoesNotExist
+	}
+	if len(entry.Senses) == 0 {
+		_, err := g.AddSense(word, Sense{Definition: definition})
+		return err
+	}
+
+	sense := entry.Senses[0]
+	sense.Definition = definition
+	return g.UpdateSense(word, 0, sense)
+}
+
+// Delete removes the word and any links other entries had to it, so SeeAlso never points at nothing.
+func (g Glossary) Delete(word string) error {
+	entry, ok := g[word]
+	if !ok {
+		return ErrWordDoesNotExist
+	}
+
+	for _, linked := range entry.SeeAlso {
+		other := g[linked]
+		other.SeeAlso = slices.DeleteFunc(slices.Clone(other.SeeAlso), func(w string) bool { return w == word })
+		g[linked] = other
+	}
+	delete(g, word)
+	return nil
+}
+
+// Entry returns a copy of the word's entry, so changing it doesn't change the glossary behind our back.
+func (g Glossary) Entry(word string) (Entry, error) {
+	entry, ok := g[word]
+	if !ok {
+		return Entry{}, ErrNotFound
+	}
+	return entry.clone(), nil
+}
+
+// AddEntry adds a new word with all its senses. Links are made with Link rather than here,
+// because the words at the other end need to know about them too.
+func (g Glossary) AddEntry(entry Entry) error {
+	if _, ok := g[entry.Word]; ok {
+		return ErrWordExists
+	}
+	if len(entry.Senses) == 0 {
+		return ErrNoSenses
+	}
+	for _, sense := range entry.Senses {
+		if err := sense.validate(); err != nil {
+			return err
+		}
+	}
+
+	entry = entry.clone()
+	entry.SeeAlso = nil
+	g[entry.Word] = entry
+	return nil
+}
+
+// AddSense appends a sense to an existing word and returns its index.
+func (g Glossary) AddSense(word string, sense Sense) (int, error) {
+	entry, ok := g[word]
+	if !ok {
+		return 0, ErrWordDoesNotExist
+	}
+	if err := sense.validate(); err != nil {
+		return 0, err
+	}
+
+	entry.Senses = append(slices.Clone(entry.Senses), sense.clone())
+	g[word] = entry
+	return len(entry.Senses) - 1, nil
+}
+
+// UpdateSense replaces the sense at index, counting from 0.
+func (g Glossary) UpdateSense(word string, index int, sense Sense) error {
+	entry, ok := g[word]
+	if !ok {
+		return ErrWordDoesNotExist
+	}
+	if index < 0 || index >= len(entry.Senses) {
+		return ErrSenseNotFound
+	}
+	if err := sense.validate(); err != nil {
+		return err
+	}
+
+	entry.Senses = slices.Clone(entry.Senses)
+	entry.Senses[index] = sense.clone()
+	g[word] = entry
+	return nil
+}
+
+func (g Glossary) RemoveSense(word string, index int) error {
+	entry, ok := g[word]
+	if !ok {
+		return ErrWordDoesNotExist
+	}
+	if index < 0 || index >= len(entry.Senses) {
+		return ErrSenseNotFound
+	}
+	if len(entry.Senses) == 1 {
+		return ErrLastSense
+	}
+
+	entry.Senses = slices.Delete(slices.Clone(entry.Senses), index, index+1)
+	g[word] = entry
+	return nil
+}
+
+// Link cross-references two entries. Linking words that are already linked does nothing.
+func (g Glossary) Link(word, other string) error {
+	if word == other {
+		return ErrSelfLink
+	}
+	from, ok := g[word]
+	if !ok {
+		return ErrNotFound
+	}
+	to, ok := g[other]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if !slices.Contains(from.SeeAlso, other) {
+		from.SeeAlso = append(slices.Clone(from.SeeAlso), other)
+		g[word] = from
+	}
+	if !slices.Contains(to.SeeAlso, word) {
+		to.SeeAlso = append(slices.Clone(to.SeeAlso), word)
+		g[other] = to
+	}
+	return nil
+}
+
+func (g Glossary) Unlink(word, other string) error {
+	from, ok := g[word]
+	if !ok {
+		return ErrNotFound
+	}
+	to, ok := g[other]
+	if !ok {
+		return ErrNotFound
+	}
+	if !slices.Contains(from.SeeAlso, other) {
+		return ErrLinkDoesNotExist
+	}
+
+	from.SeeAlso = slices.DeleteFunc(slices.Clone(from.SeeAlso), func(w string) bool { return w == other })
+	to.SeeAlso = slices.DeleteFunc(slices.Clone(to.SeeAlso), func(w string) bool { return w == word })
+	g[word] = from
+	g[other] = to
+	return nil
+}
+
+// Dictionary flattens the glossary down to one definition per word, the same one Search gives you.
+func (g Glossary) Dictionary() Dictionary {
+	d := make(Dictionary, len(g))
+	for word, entry := range g {
+		if len(entry.Senses) > 0 {
+			d[word] = entry.Senses[0].Definition
+		}
+	}
+	return d
+}
+
+func (s Sense) validate() error {
+	if s.Definition == "" {
+		return ErrEmptyDefinition
+	}
+	return nil
+}
+
+// The slices in an Entry would otherwise be shared between the copy we hand out and the one we keep.
+func (s Sense) clone() Sense {
+	s.Examples = slices.Clone(s.Examples)
+	s.Synonyms = slices.Clone(s.Synonyms)
+	s.Antonyms = slices.Clone(s.Antonyms)
+	return s
+}
+
+func (e Entry) clone() Entry {
+	senses := make([]Sense, len(e.Senses))
+	for i, sense := range e.Senses {
+		senses[i] = sense.clone()
+	}
+	e.Senses = senses
+	e.SeeAlso = slices.Clone(e.SeeAlso)
+	return e
+}
@@ -0,0 +1,244 @@
+package dictionary
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGlossaryStringAPI(t *testing.T) {
+	t.Run("works like a Dictionary", func(t *testing.T) {
+		glossary := Glossary{}
+
+		assertError(t, glossary.Add("test", "this is just a test"), nil)
+		assertError(t, glossary.Add("test", "again"), ErrWordExists)
+		assertGlossaryDefinition(t, glossary, "test", "this is just a test")
+
+		assertError(t, glossary.Update("test", "new definition"), nil)
+		assertError(t, glossary.Update("unknown", "definition"), ErrWordDoesNotExist)
+		assertGlossaryDefinition(t, glossary, "test", "new definition")
+
+		assertError(t, glossary.Delete("test"), nil)
+		assertError(t, glossary.Delete("test"), ErrWordDoesNotExist)
+		_, err := glossary.Search("test")
+		assertError(t, err, ErrNotFound)
+	})
+
+	t.Run("unlike a Dictionary it refuses an empty definition", func(t *testing.T) {
+		glossary := Glossary{}
+
+		assertError(t, glossary.Add("test", ""), ErrEmptyDefinition)
+		glossary.Add("test", "this is just a test")
+		assertError(t, glossary.Update("test", ""), ErrEmptyDefinition)
+		assertGlossaryDefinition(t, glossary, "test", "this is just a test")
+	})
+
+	t.Run("an entry without senses has no definition", func(t *testing.T) {
+		glossary := Glossary{"empty": {Word: "empty"}}
+
+		_, err := glossary.Search("empty")
+		assertError(t, err, ErrNotFound)
+		if got := glossary.Dictionary(); len(got) != 0 {
+			t.Errorf("got %v want nothing", got)
+		}
+
+		assertError(t, glossary.Update("empty", "has nothing in it"), nil)
+		assertGlossaryDefinition(t, glossary, "empty", "has nothing in it")
+	})
+
+	t.Run("shows and updates the first sense", func(t *testing.T) {
+		glossary := Glossary{}
+		glossary.AddEntry(Entry{Word: "run", Senses: []Sense{
+			{PartOfSpeech: Verb, Definition: "move quickly on foot", Synonyms: []string{"sprint"}},
+			{PartOfSpeech: Noun, Definition: "an act of running"},
+		}})
+
+		assertGlossaryDefinition(t, glossary, "run", "move quickly on foot")
+
+		glossary.Update("run", "go faster than walking")
+
+		entry, _ := glossary.Entry("run")
+		want := []Sense{
+			{PartOfSpeech: Verb, Definition: "go faster than walking", Synonyms: []string{"sprint"}},
+			{PartOfSpeech: Noun, Definition: "an act of running"},
+		}
+		assertSenses(t, entry.Senses, want)
+	})
+
+	t.Run("flattens into a Dictionary", func(t *testing.T) {
+		glossary := Glossary{}
+		glossary.AddEntry(Entry{Word: "bank", Senses: []Sense{{Definition: "side of a river"}, {Definition: "place for money"}}})
+		glossary.Add("test", "this is just a test")
+
+		want := Dictionary{"bank": "side of a river", "test": "this is just a test"}
+		if got := glossary.Dictionary(); !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v want %v", got, want)
+		}
+	})
+}
+
+func TestGlossarySenses(t *testing.T) {
+	newGlossary := func() Glossary {
+		glossary := Glossary{}
+		glossary.Add("bank", "side of a river")
+		return glossary
+	}
+
+	t.Run("add a sense", func(t *testing.T) {
+		glossary := newGlossary()
+
+		index, err := glossary.AddSense("bank", Sense{PartOfSpeech: Noun, Definition: "place for money", Examples: []string{"I went to the bank"}})
+
+		assertError(t, err, nil)
+		if index != 1 {
+			t.Errorf("got index %d want 1", index)
+		}
+		entry, _ := glossary.Entry("bank")
+		assertSenses(t, entry.Senses, []Sense{
+			{Definition: "side of a river"},
+			{PartOfSpeech: Noun, Definition: "place for money", Examples: []string{"I went to the bank"}},
+		})
+	})
+
+	t.Run("update a sense", func(t *testing.T) {
+		glossary := newGlossary()
+		glossary.AddSense("bank", Sense{Definition: "place for money"})
+
+		err := glossary.UpdateSense("bank", 1, Sense{PartOfSpeech: Noun, Definition: "a financial institution", Antonyms: []string{"mattress"}})
+
+		assertError(t, err, nil)
+		entry, _ := glossary.Entry("bank")
+		assertSenses(t, entry.Senses, []Sense{
+			{Definition: "side of a river"},
+			{PartOfSpeech: Noun, Definition: "a financial institution", Antonyms: []string{"mattress"}},
+		})
+	})
+
+	t.Run("remove a sense", func(t *testing.T) {
+		glossary := newGlossary()
+		glossary.AddSense("bank", Sense{Definition: "place for money"})
+
+		assertError(t, glossary.RemoveSense("bank", 0), nil)
+		assertGlossaryDefinition(t, glossary, "bank", "place for money")
+		assertError(t, glossary.RemoveSense("bank", 0), ErrLastSense)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		glossary := newGlossary()
+
+		_, err := glossary.AddSense("unknown", Sense{Definition: "definition"})
+		assertError(t, err, ErrWordDoesNotExist)
+		_, err = glossary.AddSense("bank", Sense{PartOfSpeech: Noun})
+		assertError(t, err, ErrEmptyDefinition)
+
+		assertError(t, glossary.UpdateSense("bank", 1, Sense{Definition: "definition"}), ErrSenseNotFound)
+		assertError(t, glossary.UpdateSense("bank", -1, Sense{Definition: "definition"}), ErrSenseNotFound)
+		assertError(t, glossary.UpdateSense("unknown", 0, Sense{Definition: "definition"}), ErrWordDoesNotExist)
+		assertError(t, glossary.RemoveSense("bank", 3), ErrSenseNotFound)
+
+		assertError(t, glossary.AddEntry(Entry{Word: "empty"}), ErrNoSenses)
+		assertError(t, glossary.AddEntry(Entry{Word: "bank", Senses: []Sense{{Definition: "again"}}}), ErrWordExists)
+
+		_, err = glossary.Entry("unknown")
+		assertError(t, err, ErrNotFound)
+	})
+
+	t.Run("entries handed out are copies", func(t *testing.T) {
+		glossary := Glossary{}
+		sense := Sense{Definition: "move quickly", Synonyms: []string{"sprint"}}
+		glossary.AddEntry(Entry{Word: "run", Senses: []Sense{sense}})
+		sense.Synonyms[0] = "changed"
+
+		entry, _ := glossary.Entry("run")
+		entry.Senses[0].Synonyms[0] = "changed"
+		entry.Senses[0].Definition = "changed"
+
+		got, _ := glossary.Entry("run")
+		assertSenses(t, got.Senses, []Sense{{Definition: "move quickly", Synonyms: []string{"sprint"}}})
+	})
+}
+
+func TestGlossaryLinks(t *testing.T) {
+	newGlossary := func() Glossary {
+		glossary := Glossary{}
+		glossary.Add("map", "a collection of key value pairs")
+		glossary.Add("hash", "a fixed size summary of some data")
+		glossary.Add("slice", "a view of an array")
+		return glossary
+	}
+
+	t.Run("links go both ways", func(t *testing.T) {
+		glossary := newGlossary()
+
+		assertError(t, glossary.Link("map", "hash"), nil)
+		assertError(t, glossary.Link("hash", "map"), nil)
+		assertError(t, glossary.Link("map", "slice"), nil)
+
+		assertSeeAlso(t, glossary, "map", "hash", "slice")
+		assertSeeAlso(t, glossary, "hash", "map")
+		assertSeeAlso(t, glossary, "slice", "map")
+	})
+
+	t.Run("unlink", func(t *testing.T) {
+		glossary := newGlossary()
+		glossary.Link("map", "hash")
+
+		assertError(t, glossary.Unlink("hash", "map"), nil)
+		assertError(t, glossary.Unlink("hash", "map"), ErrLinkDoesNotExist)
+
+		assertSeeAlso(t, glossary, "map")
+		assertSeeAlso(t, glossary, "hash")
+	})
+
+	t.Run("deleting a word removes links to it", func(t *testing.T) {
+		glossary := newGlossary()
+		glossary.Link("map", "hash")
+		glossary.Link("slice", "hash")
+
+		glossary.Delete("hash")
+
+		assertSeeAlso(t, glossary, "map")
+		assertSeeAlso(t, glossary, "slice")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		glossary := newGlossary()
+
+		assertError(t, glossary.Link("map", "map"), ErrSelfLink)
+		assertError(t, glossary.Link("map", "unknown"), ErrNotFound)
+		assertError(t, glossary.Link("unknown", "map"), ErrNotFound)
+		assertError(t, glossary.Unlink("unknown", "map"), ErrNotFound)
+	})
+}
+
+func assertGlossaryDefinition(t testing.TB, glossary Glossary, word, definition string) {
+	t.Helper()
+
+	got, err := glossary.Search(word)
+	if err != nil {
+		t.Fatal("should find added word:", err)
+	}
+	assertStrings(t, got, definition)
+}
+
+func assertSenses(t testing.TB, got, want []Sense) {
+	t.Helper()
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got senses %+v want %+v", got, want)
+	}
+}
+
+func assertSeeAlso(t testing.TB, glossary Glossary, word string, want ...string) {
+	t.Helper()
+
+	entry, err := glossary.Entry(word)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entry.SeeAlso) == 0 && len(want) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(entry.SeeAlso, want) {
+		t.Errorf("%s: got links %v want %v", word, entry.SeeAlso, want)
+	}
+}
@@ -1,3 +1,10 @@
 module maps_dictionary_chapter
 
 go 1.22.5
+
+require (
+	golang.org/x/term v0.29.0
+	golang.org/x/text v0.22.0
+)
+
+require golang.org/x/sys v0.30.0 // indirect
@@ -1,4 +1,6 @@
-package main
+package dictionary
+
+import "slices"
 
 // make a custom type
 type Dictionary map[string]string
@@ -94,10 +96,34 @@ func (d Dictionary) Update(word, definition string) error {
 	return nil
 }
 
-func (d Dictionary) Delete(word string) {
+func (d Dictionary) Delete(word string) error {
 	// Go has a built-in function delete that works on maps.
 	// It takes two arguments. The first is the map and the second is the key to be removed.
-	// The delete function returns nothing, and we based our Delete method on the same notion
-	// Since deleting a value that's not there has no effect, unlike our Update and Add methods, we don't need to complicate the API with errors.
-	delete(d, word)
+	// The delete function returns nothing, and we first based our Delete method on the same notion:
+	// deleting a value that's not there has no effect, so why complicate the API with errors?
+	// Once the dictionary was behind an HTTP API, though, callers wanted to know whether there was
+	// anything to delete, so like Update we now report ErrWordDoesNotExist.
+	_, err := d.Search(word)
+
+	switch err {
+	case ErrNotFound:
+		return ErrWordDoesNotExist
+	case nil:
+		delete(d, word)
+	default:
+		return err
+	}
+
+	return nil
+}
+
+// Words lists every word in alphabetical order.
+// Ranging over a map gives you the keys in a random order, so we have to sort them ourselves.
+func (d Dictionary) Words() []string {
+	words := make([]string, 0, len(d))
+	for word := range d {
+		words = append(words, word)
+	}
+	slices.Sort(words)
+	return words
 }
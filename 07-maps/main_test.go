@@ -1,4 +1,4 @@
-package main
+package dictionary
 
 import "testing"
 
@@ -69,15 +69,26 @@ func TestUpdate(t *testing.T) {
 }
 
 func TestDelete(t *testing.T) {
-	word := "test"
-	dictionary := Dictionary{word: "test definition"}
+	t.Run("existing word", func(t *testing.T) {
+		word := "test"
+		dictionary := Dictionary{word: "test definition"}
 
-	dictionary.Delete(word)
+		err := dictionary.Delete(word)
 
-	_, err := dictionary.Search(word)
-	if err != ErrNotFound {
-		t.Errorf("Expected %q to be deleted", word)
-	}
+		assertError(t, err, nil)
+		_, err = dictionary.Search(word)
+		if err != ErrNotFound {
+			t.Errorf("Expected %q to be deleted", word)
+		}
+	})
+
+	t.Run("non-existing word", func(t *testing.T) {
+		dictionary := Dictionary{}
+
+		err := dictionary.Delete("test")
+
+		assertError(t, err, ErrWordDoesNotExist)
+	})
 }
 
 func assertStrings(t testing.TB, got, want string) {
@@ -0,0 +1,128 @@
+package dictionary
+
+import (
+	"slices"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// In a plain Dictionary "Café", "CAFÉ" and "café" are three different keys, and so is "café"
+// typed with a separate combining accent (e + U+0301) rather than the single letter é.
+// They all look like the same word, so people add duplicates without noticing.
+//
+// NormalizingDictionary runs every word through a KeyNormalizer before using it as a key,
+// so all of those end up in the same place. The spelling the word was first added with is
+// kept for showing back to people.
+
+// KeyNormalizer turns a word into the key it is stored under.
+type KeyNormalizer func(word string) string
+
+// FoldKey makes words that only differ in case or in how their accents are encoded the same key.
+//
+// Unicode case folding is more than lower casing: it also maps "ß" to "ss" and the Greek final
+// sigma to an ordinary one. Folding can split a letter from its accent, so we decompose first
+// (NFD), fold, and compose again (NFC) to get one canonical form.
+func FoldKey(word string) string {
+	return transformString(word, norm.NFD, cases.Fold(), norm.NFC)
+}
+
+// FoldKeyIgnoringAccents does everything FoldKey does and also drops accents, so "cafe" finds "Café".
+// Decomposing puts every accent in its own combining mark (Unicode category Mn), which we remove.
+func FoldKeyIgnoringAccents(word string) string {
+	return transformString(word, norm.NFD, cases.Fold(), runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+}
+
+func transformString(word string, transformers ...transform.Transformer) string {
+	// transform.Chain keeps state between calls, so we need a new one for every word
+	result, _, err := transform.String(transform.Chain(transformers...), word)
+	if err != nil {
+		// only happens on invalid UTF-8, which is still better as its own key than lost
+		return word
+	}
+	return result
+}
+
+type spelledDefinition struct {
+	spelling   string
+	definition string
+}
+
+type NormalizingDictionary struct {
+	normalize KeyNormalizer
+	words     map[string]spelledDefinition
+}
+
+func NewNormalizingDictionary(normalize KeyNormalizer) *NormalizingDictionary {
+	return &NormalizingDictionary{normalize: normalize, words: map[string]spelledDefinition{}}
+}
+
+func (d *NormalizingDictionary) Search(word string) (string, error) {
+	entry, ok := d.words[d.normalize(word)]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return entry.definition, nil
+}
+
+// Spelling returns the word as it was first added, whichever way you spell it when you ask.
+func (d *NormalizingDictionary) Spelling(word string) (string, error) {
+	entry, ok := d.words[d.normalize(word)]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return entry.spelling, nil
+}
+
+func (d *NormalizingDictionary) Add(word, definition string) error {
+	key := d.normalize(word)
+	if _, ok := d.words[key]; ok {
+		return ErrWordExists
+	}
+	// we still store the composed form, so the same spelling always comes back the same way
+	d.words[key] = spelledDefinition{spelling: norm.NFC.String(word), definition: definition}
+	return nil
+}
+
+// Update changes the definition but keeps the original spelling.
+func (d *NormalizingDictionary) Update(word, definition string) error {
+	key := d.normalize(word)
+	entry, ok := d.words[key]
+	if !ok {
+		return ErrWordDoesNotExist
+	}
+	entry.definition = definition
+	d.words[key] = entry
+	return nil
+}
+
+func (d *NormalizingDictionary) Delete(word string) error {
+	key := d.normalize(word)
+	if _, ok := d.words[key]; !ok {
+		return ErrWordDoesNotExist
+	}
+	delete(d.words, key)
+	return nil
+}
+
+// Words returns the display spelling of every word, sorted.
+func (d *NormalizingDictionary) Words() []string {
+	words := make([]string, 0, len(d.words))
+	for _, entry := range d.words {
+		words = append(words, entry.spelling)
+	}
+	slices.Sort(words)
+	return words
+}
+
+// Dictionary gives you a plain Dictionary keyed by the display spellings.
+func (d *NormalizingDictionary) Dictionary() Dictionary {
+	dictionary := make(Dictionary, len(d.words))
+	for _, entry := range d.words {
+		dictionary[entry.spelling] = entry.definition
+	}
+	return dictionary
+}
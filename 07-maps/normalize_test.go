@@ -0,0 +1,122 @@
+package dictionary
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFoldKey(t *testing.T) {
+	composed := "café"
+	decomposed := "cafe\u0301"
+
+	cases := []struct {
+		a, b string
+		same bool
+	}{
+		{composed, decomposed, true},
+		{"Café", "CAFÉ", true},
+		{"CAFE\u0301", composed, true},
+		{"Straße", "STRASSE", true},
+		{"ΣΊΣΥΦΟΣ", "σίσυφος", true},
+		{"café", "cafe", false},
+		{"resume", "résumé", false},
+	}
+
+	for _, c := range cases {
+		if got := FoldKey(c.a) == FoldKey(c.b); got != c.same {
+			t.Errorf("FoldKey(%q) == FoldKey(%q) is %v, want %v", c.a, c.b, got, c.same)
+		}
+	}
+}
+
+func TestFoldKeyIgnoringAccents(t *testing.T) {
+	cases := [][2]string{
+		{"café", "CAFE"},
+		{"cafe\u0301", "cafe"},
+		{"résumé", "Resume"},
+		{"Ångström", "angstrom"},
+	}
+
+	for _, c := range cases {
+		if FoldKeyIgnoringAccents(c[0]) != FoldKeyIgnoringAccents(c[1]) {
+			t.Errorf("want %q and %q to be the same key, got %q and %q", c[0], c[1], FoldKeyIgnoringAccents(c[0]), FoldKeyIgnoringAccents(c[1]))
+		}
+	}
+
+	if FoldKeyIgnoringAccents("café") == FoldKeyIgnoringAccents("cafes") {
+		t.Error("different words should stay different")
+	}
+}
+
+func TestNormalizingDictionary(t *testing.T) {
+	t.Run("every spelling finds the same word", func(t *testing.T) {
+		dictionary := NewNormalizingDictionary(FoldKey)
+		assertError(t, dictionary.Add("Café", "a small restaurant"), nil)
+
+		for _, spelling := range []string{"Café", "café", "CAFÉ", "cafe\u0301", "CAFE\u0301"} {
+			definition, err := dictionary.Search(spelling)
+			assertError(t, err, nil)
+			assertStrings(t, definition, "a small restaurant")
+		}
+
+		_, err := dictionary.Search("cafe")
+		assertError(t, err, ErrNotFound)
+	})
+
+	t.Run("no duplicates", func(t *testing.T) {
+		dictionary := NewNormalizingDictionary(FoldKey)
+		dictionary.Add("Café", "a small restaurant")
+
+		assertError(t, dictionary.Add("cafe\u0301", "again"), ErrWordExists)
+		assertWords(t, dictionary.Words(), "Café")
+	})
+
+	t.Run("update and delete with a different spelling", func(t *testing.T) {
+		dictionary := NewNormalizingDictionary(FoldKey)
+		dictionary.Add("Café", "a small restaurant")
+
+		assertError(t, dictionary.Update("CAFÉ", "somewhere to get coffee"), nil)
+		assertError(t, dictionary.Update("tea", "a drink"), ErrWordDoesNotExist)
+
+		spelling, _ := dictionary.Spelling("café")
+		assertStrings(t, spelling, "Café")
+		definition, _ := dictionary.Search("Café")
+		assertStrings(t, definition, "somewhere to get coffee")
+
+		assertError(t, dictionary.Delete("cafe\u0301"), nil)
+		assertError(t, dictionary.Delete("cafe\u0301"), ErrWordDoesNotExist)
+		_, err := dictionary.Search("Café")
+		assertError(t, err, ErrNotFound)
+		_, err = dictionary.Spelling("Café")
+		assertError(t, err, ErrNotFound)
+	})
+
+	t.Run("display spelling is stored composed", func(t *testing.T) {
+		dictionary := NewNormalizingDictionary(FoldKey)
+		dictionary.Add("Cafe\u0301", "a small restaurant")
+
+		spelling, _ := dictionary.Spelling("café")
+		assertStrings(t, spelling, "Café")
+	})
+
+	t.Run("ignoring accents", func(t *testing.T) {
+		dictionary := NewNormalizingDictionary(FoldKeyIgnoringAccents)
+		dictionary.Add("Café", "a small restaurant")
+
+		definition, err := dictionary.Search("cafe")
+		assertError(t, err, nil)
+		assertStrings(t, definition, "a small restaurant")
+		assertError(t, dictionary.Add("CAFE", "again"), ErrWordExists)
+	})
+
+	t.Run("as a plain Dictionary", func(t *testing.T) {
+		dictionary := NewNormalizingDictionary(FoldKey)
+		dictionary.Add("Café", "a small restaurant")
+		dictionary.Add("tea", "a drink")
+
+		want := Dictionary{"Café": "a small restaurant", "tea": "a drink"}
+		if got := dictionary.Dictionary(); !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v want %v", got, want)
+		}
+	})
+}
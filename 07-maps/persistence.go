@@ -0,0 +1,333 @@
+package dictionary
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Format is how a Dictionary is laid out in a file.
+//
+//	json  {"word": "definition", ...}
+//	csv   a "word,definition" header row, then one word per row
+//	tsv   the same as csv but with tabs
+type Format string
+
+const (
+	JSON = Format("json")
+	CSV  = Format("csv")
+	TSV  = Format("tsv")
+)
+
+const ErrUnknownFormat = DictionaryErr("unknown file format, want json, csv or tsv")
+
+// FormatFromPath picks the format from the file extension.
+func FormatFromPath(path string) (Format, error) {
+	switch format := Format(strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))); format {
+	case JSON, CSV, TSV:
+		return format, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+// Write writes every word out in format, sorted so the same dictionary always gives the same file.
+func (d Dictionary) Write(w io.Writer, format Format) error {
+	switch format {
+	case JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		// encoding/json sorts map keys for us
+		return encoder.Encode(map[string]string(d))
+	case CSV, TSV:
+		writer := csv.NewWriter(w)
+		if format == TSV {
+			writer.Comma = '\t'
+		}
+		writer.Write([]string{"word", "definition"})
+		for _, word := range d.Words() {
+			writer.Write([]string{word, d[word]})
+		}
+		writer.Flush()
+		return writer.Error()
+	default:
+		return ErrUnknownFormat
+	}
+}
+
+// Save writes to a temporary file next to path and renames it over path once it is safely on disk.
+// A rename is atomic, so if we crash part way through, path still holds the old dictionary
+// rather than half of the new one.
+func (d Dictionary) Save(path string, format Format) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	// after a successful rename there's nothing left to remove, so this only tidies up failures
+	defer os.Remove(temp.Name())
+
+	if err := d.Write(temp, format); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return err
+	}
+
+	// the rename itself is only durable once the directory has been synced
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+// Load reads a whole dictionary from path. Any bad line or repeated word is an error, and nothing is loaded.
+func Load(path string, format Format) (Dictionary, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	d := Dictionary{}
+	result, err := d.Import(file, format, ConflictFail)
+	if err != nil {
+		lineErrors := make([]error, len(result.Errors))
+		for i := range result.Errors {
+			lineErrors[i] = result.Errors[i]
+		}
+		return nil, errors.Join(append([]error{err}, lineErrors...)...)
+	}
+	return d, nil
+}
+
+// ConflictStrategy is what Import does with a word the dictionary already has.
+type ConflictStrategy int
+
+const (
+	// ConflictSkip keeps the existing definition
+	ConflictSkip ConflictStrategy = iota
+	// ConflictOverwrite replaces it using Update
+	ConflictOverwrite
+	// ConflictFail reports ErrWordExists for the line and, like any other bad line, stops the whole import
+	ConflictFail
+)
+
+const ErrImportAborted = DictionaryErr("import aborted, the dictionary was not changed")
+
+// LineError says which line of an import went wrong.
+type LineError struct {
+	Line int
+	Word string
+	Err  error
+}
+
+func (e LineError) Error() string {
+	if e.Word == "" {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+	return fmt.Sprintf("line %d: %q: %v", e.Line, e.Word, e.Err)
+}
+
+func (e LineError) Unwrap() error {
+	return e.Err
+}
+
+type ImportResult struct {
+	Added   int
+	Updated int
+	Skipped int
+	Errors  []LineError
+}
+
+type importRecord struct {
+	line             int
+	word, definition string
+}
+
+const ErrEmptyWord = DictionaryErr("word cannot be empty")
+
+// Import adds every word it reads to the dictionary, going through Add and Update
+// so it follows exactly the same rules as adding the words one at a time.
+// Lines that can't be read are reported in the result and skipped,
+// unless strategy is ConflictFail, in which case any problem means nothing is imported.
+func (d Dictionary) Import(r io.Reader, format Format, strategy ConflictStrategy) (ImportResult, error) {
+	records, lineErrors, err := readRecords(r, format)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	// with ConflictFail we try the import on a copy first, so we can throw it away if anything goes wrong
+	target := d
+	if strategy == ConflictFail {
+		target = maps.Clone(d)
+	}
+
+	result := ImportResult{Errors: lineErrors}
+	for _, record := range records {
+		err := target.Add(record.word, record.definition)
+
+		switch {
+		case err == nil:
+			result.Added++
+		case errors.Is(err, ErrWordExists) && strategy == ConflictSkip:
+			result.Skipped++
+		case errors.Is(err, ErrWordExists) && strategy == ConflictOverwrite:
+			if err := target.Update(record.word, record.definition); err != nil {
+				result.Errors = append(result.Errors, LineError{record.line, record.word, err})
+				continue
+			}
+			result.Updated++
+		default:
+			result.Errors = append(result.Errors, LineError{record.line, record.word, err})
+		}
+	}
+
+	slices.SortStableFunc(result.Errors, func(a, b LineError) int { return a.Line - b.Line })
+
+	if strategy == ConflictFail {
+		if len(result.Errors) > 0 {
+			return ImportResult{Errors: result.Errors}, ErrImportAborted
+		}
+		maps.Copy(d, target)
+	}
+
+	return result, nil
+}
+
+// readRecords returns the good records and an error for each bad line.
+// The returned error is only for when the input can't be read at all.
+func readRecords(r io.Reader, format Format) ([]importRecord, []LineError, error) {
+	switch format {
+	case JSON:
+		return readJSONRecords(r)
+	case CSV:
+		return readDelimitedRecords(r, ',')
+	case TSV:
+		return readDelimitedRecords(r, '\t')
+	default:
+		return nil, nil, ErrUnknownFormat
+	}
+}
+
+const ErrBadRecord = DictionaryErr("expected a word and a definition")
+
+func readDelimitedRecords(r io.Reader, comma rune) ([]importRecord, []LineError, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = comma
+	// we check the number of fields ourselves so it's reported against the line
+	reader.FieldsPerRecord = -1
+
+	var records []importRecord
+	var lineErrors []LineError
+
+	for first := true; ; first = false {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			lineErrors = append(lineErrors, LineError{Line: parseErr.StartLine, Err: parseErr.Err})
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		if first && len(fields) == 2 && fields[0] == "word" && fields[1] == "definition" {
+			continue
+		}
+		if len(fields) != 2 {
+			lineErrors = append(lineErrors, LineError{Line: line, Err: fmt.Errorf("%w, got %d fields", ErrBadRecord, len(fields))})
+			continue
+		}
+		if fields[0] == "" {
+			lineErrors = append(lineErrors, LineError{Line: line, Err: ErrEmptyWord})
+			continue
+		}
+		records = append(records, importRecord{line, fields[0], fields[1]})
+	}
+
+	return records, lineErrors, nil
+}
+
+// readJSONRecords walks the object a token at a time rather than decoding it into a map,
+// which would quietly drop repeated words and lose track of which line each came from.
+func readJSONRecords(r io.Reader) ([]importRecord, []LineError, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	lineAt := func(offset int64) int {
+		return bytes.Count(data[:offset], []byte("\n")) + 1
+	}
+
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, []LineError{{Line: lineAt(decoder.InputOffset()), Err: fmt.Errorf("%w: want a JSON object of words to definitions", ErrBadRecord)}}, nil
+	}
+
+	var records []importRecord
+	var lineErrors []LineError
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			lineErrors = append(lineErrors, LineError{Line: lineAt(decoder.InputOffset()), Err: err})
+			return records, lineErrors, nil
+		}
+		word := token.(string)
+		line := lineAt(decoder.InputOffset())
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			lineErrors = append(lineErrors, LineError{Line: line, Word: word, Err: err})
+			return records, lineErrors, nil
+		}
+
+		var definition string
+		if err := json.Unmarshal(value, &definition); err != nil {
+			lineErrors = append(lineErrors, LineError{Line: line, Word: word, Err: fmt.Errorf("%w: definition must be a string", ErrBadRecord)})
+			continue
+		}
+		if word == "" {
+			lineErrors = append(lineErrors, LineError{Line: line, Err: ErrEmptyWord})
+			continue
+		}
+		records = append(records, importRecord{line, word, definition})
+	}
+
+	// the object has to be closed, and be the only thing in the file
+	if token, err := decoder.Token(); err != nil || token != json.Delim('}') {
+		lineErrors = append(lineErrors, LineError{Line: lineAt(decoder.InputOffset()), Err: fmt.Errorf("%w: the JSON object isn't closed", ErrBadRecord)})
+		return records, lineErrors, nil
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		// point at whatever follows the object, not the end of the line it closed on
+		extra := len(data) - len(bytes.TrimLeft(data[decoder.InputOffset():], " \t\r\n"))
+		lineErrors = append(lineErrors, LineError{Line: lineAt(int64(extra)), Err: fmt.Errorf("%w: want nothing after the JSON object", ErrBadRecord)})
+	}
+
+	return records, lineErrors, nil
+}
@@ -0,0 +1,248 @@
+package dictionary
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	dictionary := Dictionary{
+		"test":  "this is just a test",
+		"comma": "a pause, written as ,",
+		"quote": `she said "hi"`,
+		"tab":   "one\ttwo",
+		"lines": "first\nsecond",
+	}
+
+	for _, format := range []Format{JSON, CSV, TSV} {
+		t.Run(string(format), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "words."+string(format))
+
+			assertError(t, dictionary.Save(path, format), nil)
+
+			got, err := Load(path, format)
+			assertError(t, err, nil)
+			if !reflect.DeepEqual(got, dictionary) {
+				t.Errorf("got %v want %v", got, dictionary)
+			}
+		})
+	}
+
+	t.Run("leaves no temporary files behind", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "words.json")
+
+		assertError(t, dictionary.Save(path, JSON), nil)
+		assertError(t, Dictionary{"test": "overwritten"}.Save(path, JSON), nil)
+
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 1 {
+			t.Errorf("want only words.json in the directory, got %v", entries)
+		}
+
+		got, _ := Load(path, JSON)
+		assertDefinition(t, got, "test", "overwritten")
+	})
+
+	t.Run("a failed save keeps the old file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "words.json")
+		assertError(t, dictionary.Save(path, JSON), nil)
+
+		err := Dictionary{"test": "new"}.Save(path, Format("xml"))
+		assertError(t, err, ErrUnknownFormat)
+
+		got, _ := Load(path, JSON)
+		assertDefinition(t, got, "test", "this is just a test")
+	})
+
+	t.Run("load reports bad lines and loads nothing", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "words.csv")
+		os.WriteFile(path, []byte("word,definition\ntest,a test\ntest,again\n"), 0o644)
+
+		got, err := Load(path, CSV)
+		if !errors.Is(err, ErrImportAborted) || !errors.Is(err, ErrWordExists) {
+			t.Errorf("got %v want %v and %v", err, ErrImportAborted, ErrWordExists)
+		}
+		if got != nil {
+			t.Errorf("got %v want nothing loaded", got)
+		}
+	})
+
+	t.Run("load refuses json with more after the object", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "words.json")
+		os.WriteFile(path, []byte(`{"test": "a test"} {"test": "again"}`), 0o644)
+
+		got, err := Load(path, JSON)
+		if !errors.Is(err, ErrBadRecord) {
+			t.Errorf("got %v want %v", err, ErrBadRecord)
+		}
+		if got != nil {
+			t.Errorf("got %v want nothing loaded", got)
+		}
+	})
+}
+
+func TestFormatFromPath(t *testing.T) {
+	cases := map[string]Format{"words.json": JSON, "words.CSV": CSV, "dir/words.tsv": TSV}
+
+	for path, want := range cases {
+		got, err := FormatFromPath(path)
+		assertError(t, err, nil)
+		if got != want {
+			t.Errorf("%s: got %q want %q", path, got, want)
+		}
+	}
+
+	_, err := FormatFromPath("words.txt")
+	assertError(t, err, ErrUnknownFormat)
+}
+
+func TestWrite(t *testing.T) {
+	dictionary := Dictionary{"zebra": "stripes", "apple": "fruit"}
+
+	var csv strings.Builder
+	dictionary.Write(&csv, CSV)
+	assertStrings(t, csv.String(), "word,definition\napple,fruit\nzebra,stripes\n")
+
+	var tsv strings.Builder
+	dictionary.Write(&tsv, TSV)
+	assertStrings(t, tsv.String(), "word\tdefinition\napple\tfruit\nzebra\tstripes\n")
+}
+
+func TestImport(t *testing.T) {
+	input := "word,definition\nexisting,new definition\nfresh,a new word\n"
+
+	t.Run("skip keeps existing definitions", func(t *testing.T) {
+		dictionary := Dictionary{"existing": "old definition"}
+
+		result, err := dictionary.Import(strings.NewReader(input), CSV, ConflictSkip)
+
+		assertError(t, err, nil)
+		assertResult(t, result, ImportResult{Added: 1, Skipped: 1})
+		assertDefinition(t, dictionary, "existing", "old definition")
+		assertDefinition(t, dictionary, "fresh", "a new word")
+	})
+
+	t.Run("overwrite updates existing definitions", func(t *testing.T) {
+		dictionary := Dictionary{"existing": "old definition"}
+
+		result, err := dictionary.Import(strings.NewReader(input), CSV, ConflictOverwrite)
+
+		assertError(t, err, nil)
+		assertResult(t, result, ImportResult{Added: 1, Updated: 1})
+		assertDefinition(t, dictionary, "existing", "new definition")
+		assertDefinition(t, dictionary, "fresh", "a new word")
+	})
+
+	t.Run("fail changes nothing and says which line clashed", func(t *testing.T) {
+		dictionary := Dictionary{"existing": "old definition"}
+
+		result, err := dictionary.Import(strings.NewReader(input), CSV, ConflictFail)
+
+		assertError(t, err, ErrImportAborted)
+		assertResult(t, result, ImportResult{Errors: []LineError{{Line: 2, Word: "existing", Err: ErrWordExists}}})
+		if !reflect.DeepEqual(dictionary, Dictionary{"existing": "old definition"}) {
+			t.Errorf("dictionary changed to %v", dictionary)
+		}
+	})
+
+	t.Run("fail imports everything when there are no conflicts", func(t *testing.T) {
+		dictionary := Dictionary{}
+
+		result, err := dictionary.Import(strings.NewReader(input), CSV, ConflictFail)
+
+		assertError(t, err, nil)
+		assertResult(t, result, ImportResult{Added: 2})
+	})
+
+	t.Run("words repeated within the input clash with each other", func(t *testing.T) {
+		dictionary := Dictionary{}
+
+		result, _ := dictionary.Import(strings.NewReader("a,first\na,second\n"), CSV, ConflictSkip)
+
+		assertResult(t, result, ImportResult{Added: 1, Skipped: 1})
+		assertDefinition(t, dictionary, "a", "first")
+	})
+
+	t.Run("bad lines are reported and the rest imported", func(t *testing.T) {
+		dictionary := Dictionary{}
+		input := "good\tfine\ntoo\tmany\tfields\n\tno word\nalso good\tfine\n"
+
+		result, err := dictionary.Import(strings.NewReader(input), TSV, ConflictSkip)
+
+		assertError(t, err, nil)
+		if result.Added != 2 {
+			t.Errorf("added %d words, want 2", result.Added)
+		}
+		assertErrorLines(t, result.Errors, 2, 3)
+		if !errors.Is(result.Errors[0], ErrBadRecord) || !errors.Is(result.Errors[1], ErrEmptyWord) {
+			t.Errorf("got %v", result.Errors)
+		}
+	})
+
+	t.Run("json keeps repeated words and line numbers", func(t *testing.T) {
+		dictionary := Dictionary{}
+		input := "{\n  \"test\": \"one\",\n  \"test\": \"two\",\n  \"number\": 42,\n  \"ok\": \"fine\"\n}\n"
+
+		result, err := dictionary.Import(strings.NewReader(input), JSON, ConflictSkip)
+
+		assertError(t, err, nil)
+		if result.Added != 2 || result.Skipped != 1 {
+			t.Errorf("got %+v", result)
+		}
+		assertErrorLines(t, result.Errors, 4)
+		assertDefinition(t, dictionary, "test", "one")
+	})
+
+	t.Run("json that isn't an object", func(t *testing.T) {
+		result, _ := Dictionary{}.Import(strings.NewReader(`["test"]`), JSON, ConflictSkip)
+
+		assertErrorLines(t, result.Errors, 1)
+	})
+
+	t.Run("json with anything after the object", func(t *testing.T) {
+		for name, input := range map[string]string{
+			"another object": "{\"test\": \"one\"}\n{\"test\": \"two\"}\n",
+			"garbage":        "{\"test\": \"one\"}\ngarbage",
+		} {
+			t.Run(name, func(t *testing.T) {
+				result, _ := Dictionary{}.Import(strings.NewReader(input), JSON, ConflictSkip)
+
+				assertErrorLines(t, result.Errors, 2)
+				if !errors.Is(result.Errors[0], ErrBadRecord) {
+					t.Errorf("got %v want %v", result.Errors[0], ErrBadRecord)
+				}
+			})
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := Dictionary{}.Import(strings.NewReader(""), Format("xml"), ConflictSkip)
+
+		assertError(t, err, ErrUnknownFormat)
+	})
+}
+
+func assertResult(t testing.TB, got, want ImportResult) {
+	t.Helper()
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v want %+v", got, want)
+	}
+}
+
+func assertErrorLines(t testing.TB, lineErrors []LineError, want ...int) {
+	t.Helper()
+
+	var got []int
+	for _, err := range lineErrors {
+		got = append(got, err.Line)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got errors on lines %v want %v (%v)", got, want, lineErrors)
+	}
+}
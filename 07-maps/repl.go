@@ -0,0 +1,401 @@
+package dictionary
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+// REPL runs dictionary commands a line at a time, for people rather than programs:
+//
+//	add gopher "the Go mascot"
+//	search gopher
+//	history gopher
+//
+// It sits on a VersionedDictionary so history can show who changed a word and undo is never
+// far away. cmd/dict reads the lines from a terminal, with tab completion, or from a script.
+// Taking writers rather than os.Stdout keeps it testable, just like Greet in the DI chapter.
+type REPL struct {
+	dictionary  *VersionedDictionary
+	author      string
+	out, errOut io.Writer
+
+	// Color makes errors red. Leave it off when the output isn't a terminal.
+	Color bool
+
+	commands []string
+}
+
+const (
+	ErrUnknownCommand = DictionaryErr("unknown command, try help")
+	ErrUsage          = DictionaryErr("wrong arguments")
+	ErrUnclosedQuote  = DictionaryErr("missing closing quote")
+	// ErrQuit is returned by Execute for quit and exit, to tell the caller to stop.
+	ErrQuit = DictionaryErr("quit")
+)
+
+type replCommand struct {
+	name, usage, help string
+}
+
+var replCommands = []replCommand{
+	{"add", "add WORD DEFINITION", "add a new word"},
+	{"update", "update WORD DEFINITION", "change the definition of a word"},
+	{"search", "search WORD", "show the definition of a word"},
+	{"delete", "delete WORD", "delete a word"},
+	{"list", "list [PREFIX]", "list every word, or those starting with PREFIX"},
+	{"load", "load FILE", "add or update every word in a .json, .csv or .tsv file"},
+	{"save", "save FILE", "save every word to a .json, .csv or .tsv file"},
+	{"history", "history [WORD]", "show the commands run so far, or every version of WORD"},
+	{"help", "help", "show this help"},
+	{"quit", "quit", "leave, exit works too"},
+}
+
+func NewREPL(dictionary *VersionedDictionary, author string, out, errOut io.Writer) *REPL {
+	return &REPL{dictionary: dictionary, author: author, out: out, errOut: errOut}
+}
+
+// Execute runs a single command. Arguments are separated by spaces and can be
+// wrapped in double quotes to include spaces. Everything after the word in add
+// and update is the definition, so it doesn't need quoting.
+func (r *REPL) Execute(line string) error {
+	args, err := splitArgs(line)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	r.commands = append(r.commands, strings.TrimSpace(line))
+
+	name, args := args[0], args[1:]
+	switch name {
+	case "add", "update":
+		if len(args) < 2 {
+			return usageError(name)
+		}
+		word, definition := args[0], strings.Join(args[1:], " ")
+		if name == "add" {
+			err = r.dictionary.Add(r.author, word, definition)
+		} else {
+			err = r.dictionary.Update(r.author, word, definition, AnyVersion)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(r.out, "%s: %s\n", word, definition)
+	case "search":
+		if len(args) != 1 {
+			return usageError(name)
+		}
+		definition, err := r.dictionary.Search(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(r.out, "%s: %s\n", args[0], definition)
+	case "delete":
+		if len(args) != 1 {
+			return usageError(name)
+		}
+		if err := r.dictionary.Delete(r.author, args[0]); err != nil {
+			return err
+		}
+		fmt.Fprintf(r.out, "deleted %s\n", args[0])
+	case "list":
+		if len(args) > 1 {
+			return usageError(name)
+		}
+		return r.list(strings.Join(args, ""))
+	case "load":
+		if len(args) != 1 {
+			return usageError(name)
+		}
+		return r.load(args[0])
+	case "save":
+		if len(args) != 1 {
+			return usageError(name)
+		}
+		return r.save(args[0])
+	case "history":
+		if len(args) > 1 {
+			return usageError(name)
+		}
+		if len(args) == 1 {
+			return r.wordHistory(args[0])
+		}
+		for i, command := range r.commands {
+			fmt.Fprintf(r.out, "%4d  %s\n", i+1, command)
+		}
+	case "help":
+		table := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+		for _, command := range replCommands {
+			fmt.Fprintf(table, "%s\t%s\n", command.usage, command.help)
+		}
+		return table.Flush()
+	case "quit", "exit":
+		return ErrQuit
+	default:
+		return fmt.Errorf("%w %q", ErrUnknownCommand, name)
+	}
+
+	return nil
+}
+
+// Run reads and executes commands until readLine returns an error, such as io.EOF,
+// or a quit command. Errors from commands are printed and don't stop it.
+func (r *REPL) Run(readLine func() (string, error)) error {
+	for {
+		line, err := readLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		err = r.Execute(line)
+		if err == ErrQuit {
+			return nil
+		}
+		if err != nil {
+			r.PrintError(err)
+		}
+	}
+}
+
+// RunScript executes the commands in a script, one per line, skipping blank lines and # comments.
+// Unlike Run it stops at the first error, so a broken fixture can't half load unnoticed.
+// The error says which line of which file it came from.
+func (r *REPL) RunScript(script io.Reader, name string) error {
+	scanner := bufio.NewScanner(script)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		err := r.Execute(line)
+		if err == ErrQuit {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", name, number, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// errorNames lets PrintError say which error it was, so people know what to look for in the code and docs.
+// More specific errors come first, as Load can return one error that is ErrImportAborted and ErrWordExists.
+var errorNames = []struct {
+	name string
+	err  error
+}{
+	{"ErrImportAborted", ErrImportAborted},
+	{"ErrNotFound", ErrNotFound},
+	{"ErrWordExists", ErrWordExists},
+	{"ErrWordDoesNotExist", ErrWordDoesNotExist},
+	{"ErrVersionConflict", ErrVersionConflict},
+	{"ErrVersionNotFound", ErrVersionNotFound},
+	{"ErrUnknownFormat", ErrUnknownFormat},
+	{"ErrBadRecord", ErrBadRecord},
+	{"ErrEmptyWord", ErrEmptyWord},
+	{"ErrUnknownCommand", ErrUnknownCommand},
+	{"ErrUsage", ErrUsage},
+	{"ErrUnclosedQuote", ErrUnclosedQuote},
+}
+
+const (
+	red   = "\x1b[31m"
+	reset = "\x1b[0m"
+)
+
+// PrintError writes err to the error output, naming the DictionaryErr it is, like
+//
+//	error: ErrWordExists: cannot add word because it already exists
+func (r *REPL) PrintError(err error) {
+	message := "error: "
+	for _, known := range errorNames {
+		if errors.Is(err, known.err) {
+			message += known.name + ": "
+			break
+		}
+	}
+	message += err.Error()
+
+	if r.Color {
+		message = red + message + reset
+	}
+	fmt.Fprintln(r.errOut, message)
+}
+
+// Complete is for golang.org/x/term's AutoCompleteCallback. When tab is pressed it completes
+// the command name, or the word after it, as far as all the possibilities agree.
+// Once there's only one possibility it adds a space too, ready for what comes next.
+func (r *REPL) Complete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+
+	before := line[:pos]
+	start := strings.LastIndex(before, " ") + 1
+	partial := before[start:]
+
+	var candidates []string
+	if strings.TrimSpace(before[:start]) == "" {
+		for _, command := range replCommands {
+			candidates = append(candidates, command.name)
+		}
+	} else {
+		candidates = r.dictionary.Words()
+	}
+	candidates = slices.DeleteFunc(candidates, func(c string) bool { return !strings.HasPrefix(c, partial) })
+	if len(candidates) == 0 {
+		return "", 0, false
+	}
+
+	completion := longestCommonPrefix(candidates)
+	if len(candidates) == 1 && !strings.HasPrefix(line[pos:], " ") {
+		completion += " "
+	}
+
+	newLine := before[:start] + completion + line[pos:]
+	return newLine, start + len(completion), true
+}
+
+func (r *REPL) list(prefix string) error {
+	dictionary := r.dictionary.Dictionary()
+
+	table := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+	for _, word := range dictionary.Words() {
+		if strings.HasPrefix(word, prefix) {
+			fmt.Fprintf(table, "%s\t%s\n", word, dictionary[word])
+		}
+	}
+	return table.Flush()
+}
+
+// load goes through Add and Update, so every word loaded gets a revision like any other change.
+func (r *REPL) load(path string) error {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+	words, err := Load(path, format)
+	if err != nil {
+		return err
+	}
+
+	changed := 0
+	for _, word := range words.Words() {
+		current, err := r.dictionary.Search(word)
+		switch {
+		case err == ErrNotFound:
+			err = r.dictionary.Add(r.author, word, words[word])
+		case err == nil && current != words[word]:
+			err = r.dictionary.Update(r.author, word, words[word], AnyVersion)
+		default:
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		changed++
+	}
+
+	fmt.Fprintf(r.out, "loaded %d words from %s, %d added or changed\n", len(words), path, changed)
+	return nil
+}
+
+func (r *REPL) save(path string) error {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+	dictionary := r.dictionary.Dictionary()
+	if err := dictionary.Save(path, format); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(r.out, "saved %d words to %s\n", len(dictionary), path)
+	return nil
+}
+
+func (r *REPL) wordHistory(word string) error {
+	revisions, err := r.dictionary.History(word)
+	if err != nil {
+		return err
+	}
+
+	table := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+	for _, revision := range revisions {
+		definition := revision.Definition
+		if revision.Deleted {
+			definition = "(deleted)"
+		}
+		fmt.Fprintf(table, "v%d\t%s\t%s\t%s\t%s\n", revision.Version, revision.Time.Format("2006-01-02 15:04"), revision.Change, revision.Author, definition)
+	}
+	return table.Flush()
+}
+
+func usageError(name string) error {
+	for _, command := range replCommands {
+		if command.name == name {
+			return fmt.Errorf("%w, usage: %s", ErrUsage, command.usage)
+		}
+	}
+	return ErrUsage
+}
+
+// splitArgs splits on spaces, except inside double quotes.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg, quoted := false, false
+
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			inArg = true
+		case r == ' ' || r == '\t':
+			if quoted {
+				current.WriteRune(r)
+				continue
+			}
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quoted {
+		return nil, ErrUnclosedQuote
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// longestCommonPrefix takes off a rune at a time, so it never splits a multi-byte letter like é in half.
+func longestCommonPrefix(words []string) string {
+	prefix := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+	return prefix
+}
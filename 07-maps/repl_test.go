@@ -0,0 +1,275 @@
+package dictionary
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestREPL() (*REPL, *strings.Builder, *strings.Builder) {
+	var out, errOut strings.Builder
+	return NewREPL(NewVersionedDictionary(&TickingClock{}), "ada", &out, &errOut), &out, &errOut
+}
+
+func TestREPLCommands(t *testing.T) {
+	t.Run("add, update, search and delete", func(t *testing.T) {
+		repl, out, _ := newTestREPL()
+
+		assertError(t, repl.Execute(`add gopher the Go mascot`), nil)
+		assertError(t, repl.Execute(`update gopher a burrowing rodent`), nil)
+		assertError(t, repl.Execute(`search gopher`), nil)
+		assertError(t, repl.Execute(`delete gopher`), nil)
+
+		assertStrings(t, out.String(), "gopher: the Go mascot\ngopher: a burrowing rodent\ngopher: a burrowing rodent\ndeleted gopher\n")
+	})
+
+	t.Run("quotes", func(t *testing.T) {
+		repl, out, _ := newTestREPL()
+
+		assertError(t, repl.Execute(`add "café au lait" "coffee,  with milk"`), nil)
+		assertError(t, repl.Execute(`search "café au lait"`), nil)
+
+		assertStrings(t, out.String(), "café au lait: coffee,  with milk\ncafé au lait: coffee,  with milk\n")
+		assertError(t, repl.Execute(`search "café`), ErrUnclosedQuote)
+	})
+
+	t.Run("dictionary errors come straight through", func(t *testing.T) {
+		repl, _, _ := newTestREPL()
+		repl.Execute("add gopher the Go mascot")
+
+		assertError(t, repl.Execute("add gopher again"), ErrWordExists)
+		assertError(t, repl.Execute("update unknown definition"), ErrWordDoesNotExist)
+		assertError(t, repl.Execute("search unknown"), ErrNotFound)
+		assertError(t, repl.Execute("delete unknown"), ErrWordDoesNotExist)
+	})
+
+	t.Run("bad commands", func(t *testing.T) {
+		repl, _, _ := newTestREPL()
+
+		for _, line := range []string{"add gopher", "search", "delete a b", "list a b", "load", "save", "history a b"} {
+			if err := repl.Execute(line); !errors.Is(err, ErrUsage) {
+				t.Errorf("%q: got %v want %v", line, err, ErrUsage)
+			}
+		}
+		if err := repl.Execute("fly away"); !errors.Is(err, ErrUnknownCommand) {
+			t.Errorf("got %v want %v", err, ErrUnknownCommand)
+		}
+		assertError(t, repl.Execute("   "), nil)
+		assertError(t, repl.Execute("quit"), ErrQuit)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		repl, out, _ := newTestREPL()
+		repl.Execute("add map a hash table")
+		repl.Execute("add maple a tree")
+		repl.Execute("add slice a view of an array")
+		out.Reset()
+
+		repl.Execute("list")
+		assertStrings(t, out.String(), "map    a hash table\nmaple  a tree\nslice  a view of an array\n")
+
+		out.Reset()
+		repl.Execute("list map")
+		assertStrings(t, out.String(), "map    a hash table\nmaple  a tree\n")
+	})
+
+	t.Run("history", func(t *testing.T) {
+		repl, out, _ := newTestREPL()
+		repl.Execute("add map a hash table")
+		repl.Execute("update map an unordered collection")
+		repl.Execute("delete map")
+		out.Reset()
+
+		assertError(t, repl.Execute("history map"), nil)
+		assertStrings(t, out.String(), ""+
+			"v1  2024-01-01 09:01  add     ada  a hash table\n"+
+			"v2  2024-01-01 09:02  update  ada  an unordered collection\n"+
+			"v3  2024-01-01 09:03  delete  ada  (deleted)\n")
+
+		out.Reset()
+		repl.Execute("history")
+		assertStrings(t, out.String(), ""+
+			"   1  add map a hash table\n"+
+			"   2  update map an unordered collection\n"+
+			"   3  delete map\n"+
+			"   4  history map\n"+
+			"   5  history\n")
+
+		assertError(t, repl.Execute("history unknown"), ErrNotFound)
+	})
+
+	t.Run("save and load", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "words.csv")
+		repl, out, _ := newTestREPL()
+		repl.Execute("add map a hash table")
+		repl.Execute("add slice a view of an array")
+
+		assertError(t, repl.Execute("save "+path), nil)
+
+		other, otherOut, _ := newTestREPL()
+		other.Execute("add map something else")
+		other.Execute("add array a fixed size sequence")
+		assertError(t, other.Execute("load "+path), nil)
+
+		assertStrings(t, out.String(), "map: a hash table\nslice: a view of an array\nsaved 2 words to "+path+"\n")
+		if !strings.HasSuffix(otherOut.String(), "loaded 2 words from "+path+", 2 added or changed\n") {
+			t.Errorf("got %q", otherOut.String())
+		}
+		assertStoredWords(t, other, Dictionary{"map": "a hash table", "slice": "a view of an array", "array": "a fixed size sequence"})
+
+		assertError(t, repl.Execute("save words.txt"), ErrUnknownFormat)
+		if err := repl.Execute("load " + filepath.Join(t.TempDir(), "missing.json")); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("got %v want %v", err, os.ErrNotExist)
+		}
+	})
+}
+
+func TestREPLErrors(t *testing.T) {
+	t.Run("names the DictionaryErr", func(t *testing.T) {
+		repl, _, errOut := newTestREPL()
+		repl.Execute("add gopher the Go mascot")
+
+		repl.PrintError(repl.Execute("add gopher again"))
+
+		assertStrings(t, errOut.String(), "error: ErrWordExists: cannot add word because it already exists\n")
+	})
+
+	t.Run("in color", func(t *testing.T) {
+		repl, _, errOut := newTestREPL()
+		repl.Color = true
+
+		repl.PrintError(repl.Execute("search gopher"))
+
+		assertStrings(t, errOut.String(), "\x1b[31merror: ErrNotFound: could not find the word you were looking for\x1b[0m\n")
+	})
+
+	t.Run("errors that aren't ours", func(t *testing.T) {
+		repl, _, errOut := newTestREPL()
+
+		repl.PrintError(io.ErrUnexpectedEOF)
+
+		assertStrings(t, errOut.String(), "error: unexpected EOF\n")
+	})
+}
+
+func TestREPLRun(t *testing.T) {
+	t.Run("keeps going after errors until the input ends", func(t *testing.T) {
+		repl, out, errOut := newTestREPL()
+		lines := []string{"search gopher", "add gopher the Go mascot", "search gopher"}
+
+		err := repl.Run(func() (string, error) {
+			if len(lines) == 0 {
+				return "", io.EOF
+			}
+			line := lines[0]
+			lines = lines[1:]
+			return line, nil
+		})
+
+		assertError(t, err, nil)
+		assertStrings(t, out.String(), "gopher: the Go mascot\ngopher: the Go mascot\n")
+		assertStrings(t, errOut.String(), "error: ErrNotFound: could not find the word you were looking for\n")
+	})
+
+	t.Run("stops at quit", func(t *testing.T) {
+		repl, _, _ := newTestREPL()
+		calls := 0
+
+		repl.Run(func() (string, error) {
+			calls++
+			return "quit", nil
+		})
+
+		if calls != 1 {
+			t.Errorf("read %d lines after quit, want 1", calls)
+		}
+	})
+}
+
+func TestREPLScript(t *testing.T) {
+	t.Run("runs every command", func(t *testing.T) {
+		repl, _, _ := newTestREPL()
+		script := "# fixtures\nadd map a hash table\n\nadd slice a view of an array\nupdate map an unordered collection\n"
+
+		assertError(t, repl.RunScript(strings.NewReader(script), "fixtures.txt"), nil)
+
+		assertStoredWords(t, repl, Dictionary{"map": "an unordered collection", "slice": "a view of an array"})
+	})
+
+	t.Run("stops at the first error and says where", func(t *testing.T) {
+		repl, _, errOut := newTestREPL()
+		script := "add map a hash table\n# oops\nadd map again\nadd slice never added\n"
+
+		err := repl.RunScript(strings.NewReader(script), "fixtures.txt")
+
+		if !errors.Is(err, ErrWordExists) {
+			t.Fatalf("got %v want %v", err, ErrWordExists)
+		}
+		repl.PrintError(err)
+		assertStrings(t, errOut.String(), "error: ErrWordExists: fixtures.txt:3: cannot add word because it already exists\n")
+		assertStoredWords(t, repl, Dictionary{"map": "a hash table"})
+	})
+
+	t.Run("quit ends the script early", func(t *testing.T) {
+		repl, _, _ := newTestREPL()
+
+		assertError(t, repl.RunScript(strings.NewReader("add map a hash table\nquit\nadd slice never added\n"), "fixtures.txt"), nil)
+		assertStoredWords(t, repl, Dictionary{"map": "a hash table"})
+	})
+}
+
+func TestREPLComplete(t *testing.T) {
+	repl, _, _ := newTestREPL()
+	for _, word := range []string{"map", "maple", "mapping", "slice", "café", "cafè"} {
+		repl.Execute("add " + word + " definition")
+	}
+
+	cases := []struct {
+		line    string
+		pos     int
+		want    string
+		wantPos int
+	}{
+		{"se", 2, "search ", 7},
+		{"s", 1, "s", 1},
+		{"search sl", 9, "search slice ", 13},
+		{"search ma", 9, "search map", 10},
+		{"search mapp", 11, "search mapping ", 15},
+		{"delete ca", 9, "delete caf", 10},
+		{"search sl trailing", 9, "search slice trailing", 12},
+	}
+
+	for _, c := range cases {
+		got, gotPos, ok := repl.Complete(c.line, c.pos, '\t')
+		if !ok || got != c.want || gotPos != c.wantPos {
+			t.Errorf("Complete(%q, %d) got %q, %d, %v want %q, %d", c.line, c.pos, got, gotPos, ok, c.want, c.wantPos)
+		}
+	}
+
+	t.Run("nothing to complete", func(t *testing.T) {
+		if _, _, ok := repl.Complete("search zebra", 12, '\t'); ok {
+			t.Error("want no completion")
+		}
+	})
+
+	t.Run("only on tab", func(t *testing.T) {
+		if _, _, ok := repl.Complete("se", 2, 'a'); ok {
+			t.Error("want other keys handled as usual")
+		}
+	})
+}
+
+func assertStoredWords(t testing.TB, repl *REPL, want Dictionary) {
+	t.Helper()
+
+	got := repl.dictionary.Dictionary()
+	if len(got) != len(want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	for word, definition := range want {
+		assertDefinition(t, got, word, definition)
+	}
+}
@@ -0,0 +1,180 @@
+package dictionary
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// WordStore is everything the server needs from a dictionary.
+// ConcurrentDictionary is the one to use, as the server handles requests concurrently.
+type WordStore interface {
+	Search(word string) (string, error)
+	Add(word, definition string) error
+	Update(word, definition string) error
+	Delete(word string) error
+	Words() []string
+}
+
+const jsonContentType = "application/json"
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 1000
+)
+
+// WordEntry is how a word and its definition look in JSON.
+type WordEntry struct {
+	Word       string `json:"word"`
+	Definition string `json:"definition"`
+}
+
+// WordPage is one page of GET /words. NextOffset is only set when there are more words to fetch.
+type WordPage struct {
+	Words      []WordEntry `json:"words"`
+	Total      int         `json:"total"`
+	Offset     int         `json:"offset"`
+	NextOffset int         `json:"nextOffset,omitempty"`
+}
+
+// DictionaryServer follows the PlayerServer pattern: embed http.Handler and set the routes up once in the constructor.
+type DictionaryServer struct {
+	store WordStore
+	http.Handler
+}
+
+func NewDictionaryServer(store WordStore) *DictionaryServer {
+	s := new(DictionaryServer)
+	s.store = store
+
+	router := http.NewServeMux()
+	router.Handle("GET /words", http.HandlerFunc(s.listHandler))
+	router.Handle("GET /words/{word}", http.HandlerFunc(s.searchHandler))
+	router.Handle("POST /words/{word}", http.HandlerFunc(s.addHandler))
+	router.Handle("PUT /words/{word}", http.HandlerFunc(s.updateHandler))
+	router.Handle("DELETE /words/{word}", http.HandlerFunc(s.deleteHandler))
+
+	s.Handler = router
+	return s
+}
+
+// listHandler takes ?prefix= to only list words starting with it, and ?offset= and ?limit= to page through them.
+func (s *DictionaryServer) listHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	offset, err := queryInt(query.Get("offset"), 0)
+	if err != nil || offset < 0 {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("offset must be a number 0 or more, got %q", query.Get("offset")))
+		return
+	}
+	limit, err := queryInt(query.Get("limit"), DefaultPageSize)
+	if err != nil || limit < 1 || limit > MaxPageSize {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("limit must be a number from 1 to %d, got %q", MaxPageSize, query.Get("limit")))
+		return
+	}
+
+	var words []string
+	for _, word := range s.store.Words() {
+		if strings.HasPrefix(word, query.Get("prefix")) {
+			words = append(words, word)
+		}
+	}
+
+	page := WordPage{Words: []WordEntry{}, Total: len(words), Offset: offset}
+	end := min(offset+limit, len(words))
+	for i := offset; i < end; i++ {
+		definition, err := s.store.Search(words[i])
+		if err != nil {
+			// deleted since we listed the words
+			continue
+		}
+		page.Words = append(page.Words, WordEntry{Word: words[i], Definition: definition})
+	}
+	if end < len(words) {
+		page.NextOffset = end
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+func (s *DictionaryServer) searchHandler(w http.ResponseWriter, r *http.Request) {
+	word := r.PathValue("word")
+
+	definition, err := s.store.Search(word)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, WordEntry{Word: word, Definition: definition})
+}
+
+func (s *DictionaryServer) addHandler(w http.ResponseWriter, r *http.Request) {
+	s.writeDefinition(w, r, s.store.Add, http.StatusCreated)
+}
+
+func (s *DictionaryServer) updateHandler(w http.ResponseWriter, r *http.Request) {
+	s.writeDefinition(w, r, s.store.Update, http.StatusOK)
+}
+
+func (s *DictionaryServer) deleteHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.store.Delete(r.PathValue("word")); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeDefinition is shared by POST and PUT, which only differ in which store method they call.
+func (s *DictionaryServer) writeDefinition(w http.ResponseWriter, r *http.Request, write func(word, definition string) error, status int) {
+	word := r.PathValue("word")
+
+	var body struct {
+		Definition string `json:"definition"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("could not decode definition: %v", err))
+		return
+	}
+
+	if err := write(word, body.Definition); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	writeJSON(w, status, WordEntry{Word: word, Definition: body.Definition})
+}
+
+// a definition is a few sentences, not a book
+const maxBodySize = 64 << 10
+
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrWordDoesNotExist):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrWordExists):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("content-type", jsonContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func queryInt(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,229 @@
+package dictionary
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDictionaryServerCRUD(t *testing.T) {
+	newServer := func() (*DictionaryServer, *ConcurrentDictionary) {
+		store := NewConcurrentDictionary()
+		store.Add("test", "this is just a test")
+		return NewDictionaryServer(store), store
+	}
+
+	t.Run("GET a word", func(t *testing.T) {
+		server, _ := newServer()
+
+		response := serve(server, http.MethodGet, "/words/test", "")
+
+		assertStatus(t, response, http.StatusOK)
+		assertJSON(t, response, WordEntry{Word: "test", Definition: "this is just a test"})
+	})
+
+	t.Run("GET a missing word", func(t *testing.T) {
+		server, _ := newServer()
+
+		response := serve(server, http.MethodGet, "/words/unknown", "")
+
+		assertStatus(t, response, http.StatusNotFound)
+		assertJSON(t, response, map[string]string{"error": ErrNotFound.Error()})
+	})
+
+	t.Run("POST adds a word", func(t *testing.T) {
+		server, store := newServer()
+
+		response := serve(server, http.MethodPost, "/words/map", `{"definition": "a hash table"}`)
+
+		assertStatus(t, response, http.StatusCreated)
+		assertJSON(t, response, WordEntry{Word: "map", Definition: "a hash table"})
+		assertConcurrentDefinition(t, store, "map", "a hash table")
+	})
+
+	t.Run("POST an existing word", func(t *testing.T) {
+		server, store := newServer()
+
+		response := serve(server, http.MethodPost, "/words/test", `{"definition": "again"}`)
+
+		assertStatus(t, response, http.StatusConflict)
+		assertJSON(t, response, map[string]string{"error": ErrWordExists.Error()})
+		assertConcurrentDefinition(t, store, "test", "this is just a test")
+	})
+
+	t.Run("PUT updates a word", func(t *testing.T) {
+		server, store := newServer()
+
+		response := serve(server, http.MethodPut, "/words/test", `{"definition": "new definition"}`)
+
+		assertStatus(t, response, http.StatusOK)
+		assertConcurrentDefinition(t, store, "test", "new definition")
+	})
+
+	t.Run("PUT a missing word", func(t *testing.T) {
+		server, _ := newServer()
+
+		response := serve(server, http.MethodPut, "/words/unknown", `{"definition": "definition"}`)
+
+		assertStatus(t, response, http.StatusNotFound)
+		assertJSON(t, response, map[string]string{"error": ErrWordDoesNotExist.Error()})
+	})
+
+	t.Run("DELETE a word", func(t *testing.T) {
+		server, store := newServer()
+
+		response := serve(server, http.MethodDelete, "/words/test", "")
+
+		assertStatus(t, response, http.StatusNoContent)
+		_, err := store.Search("test")
+		assertError(t, err, ErrNotFound)
+	})
+
+	t.Run("DELETE a missing word", func(t *testing.T) {
+		server, _ := newServer()
+
+		response := serve(server, http.MethodDelete, "/words/unknown", "")
+
+		assertStatus(t, response, http.StatusNotFound)
+		assertJSON(t, response, map[string]string{"error": ErrWordDoesNotExist.Error()})
+	})
+
+	t.Run("bad JSON", func(t *testing.T) {
+		server, _ := newServer()
+
+		response := serve(server, http.MethodPost, "/words/map", `{"definition": `)
+
+		assertStatus(t, response, http.StatusBadRequest)
+	})
+
+	t.Run("words with spaces and accents", func(t *testing.T) {
+		server, _ := newServer()
+
+		path := "/words/" + url.PathEscape("café au lait")
+		serve(server, http.MethodPost, path, `{"definition": "coffee with milk"}`)
+		response := serve(server, http.MethodGet, path, "")
+
+		assertStatus(t, response, http.StatusOK)
+		assertJSON(t, response, WordEntry{Word: "café au lait", Definition: "coffee with milk"})
+	})
+}
+
+func TestDictionaryServerList(t *testing.T) {
+	store := NewConcurrentDictionary()
+	for _, word := range []string{"apple", "apricot", "banana", "blueberry", "cherry"} {
+		store.Add(word, "a "+word)
+	}
+	server := NewDictionaryServer(store)
+
+	t.Run("everything", func(t *testing.T) {
+		response := serve(server, http.MethodGet, "/words", "")
+
+		assertStatus(t, response, http.StatusOK)
+		page := decodePage(t, response)
+		assertPageWords(t, page, "apple", "apricot", "banana", "blueberry", "cherry")
+		if page.Total != 5 || page.NextOffset != 0 {
+			t.Errorf("got %+v", page)
+		}
+		if page.Words[0].Definition != "a apple" {
+			t.Errorf("got definition %q", page.Words[0].Definition)
+		}
+	})
+
+	t.Run("pages", func(t *testing.T) {
+		page := decodePage(t, serve(server, http.MethodGet, "/words?limit=2", ""))
+		assertPageWords(t, page, "apple", "apricot")
+		if page.NextOffset != 2 {
+			t.Errorf("got next offset %d want 2", page.NextOffset)
+		}
+
+		page = decodePage(t, serve(server, http.MethodGet, fmt.Sprintf("/words?limit=2&offset=%d", page.NextOffset), ""))
+		assertPageWords(t, page, "banana", "blueberry")
+
+		page = decodePage(t, serve(server, http.MethodGet, "/words?limit=2&offset=4", ""))
+		assertPageWords(t, page, "cherry")
+		if page.NextOffset != 0 {
+			t.Errorf("last page should have no next offset, got %d", page.NextOffset)
+		}
+
+		page = decodePage(t, serve(server, http.MethodGet, "/words?offset=10", ""))
+		assertPageWords(t, page)
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		page := decodePage(t, serve(server, http.MethodGet, "/words?prefix=b", ""))
+
+		assertPageWords(t, page, "banana", "blueberry")
+		if page.Total != 2 {
+			t.Errorf("got total %d want 2", page.Total)
+		}
+	})
+
+	t.Run("bad paging", func(t *testing.T) {
+		for _, query := range []string{"limit=0", "limit=1001", "limit=ten", "offset=-1"} {
+			response := serve(server, http.MethodGet, "/words?"+query, "")
+			assertStatus(t, response, http.StatusBadRequest)
+		}
+	})
+}
+
+func serve(server http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	response := httptest.NewRecorder()
+	server.ServeHTTP(response, request)
+	return response
+}
+
+func decodePage(t testing.TB, response *httptest.ResponseRecorder) WordPage {
+	t.Helper()
+
+	var page WordPage
+	if err := json.NewDecoder(response.Body).Decode(&page); err != nil {
+		t.Fatalf("could not decode %q: %v", response.Body, err)
+	}
+	return page
+}
+
+func assertPageWords(t testing.TB, page WordPage, want ...string) {
+	t.Helper()
+
+	got := []string{}
+	for _, entry := range page.Words {
+		got = append(got, entry.Word)
+	}
+	if want == nil {
+		want = []string{}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got words %v want %v", got, want)
+	}
+}
+
+func assertStatus(t testing.TB, response *httptest.ResponseRecorder, want int) {
+	t.Helper()
+
+	if response.Code != want {
+		t.Errorf("got status %d want %d, body %q", response.Code, want, response.Body)
+	}
+}
+
+// assertJSON decodes the body into a new value of the same type as want and compares them.
+func assertJSON[T any](t testing.TB, response *httptest.ResponseRecorder, want T) {
+	t.Helper()
+
+	if got := response.Header().Get("content-type"); got != jsonContentType {
+		t.Errorf("got content-type %q want %q", got, jsonContentType)
+	}
+
+	var got T
+	if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+		t.Fatalf("could not decode %q: %v", response.Body, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v want %+v", got, want)
+	}
+}
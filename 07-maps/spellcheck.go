@@ -0,0 +1,262 @@
+package dictionary
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SpellChecker uses the words of a Dictionary as its word list. When it finds a word that isn't
+// in the list it suggests the closest ones, using the same trie search as AutocompleteDictionary.
+// Suggestions the same number of edits away are ranked by how common they are, so "teh"
+// suggests "the" before "tea". To start with, how common a word is comes from how often it's
+// used in the dictionary's own definitions. Learn can count more text.
+//
+// Words are matched whatever their case, so a word list with "Go" in it accepts "go" and "GO" too.
+type SpellChecker struct {
+	// words holds every word in the list in lower case, like the trie
+	words     map[string]bool
+	index     *trieNode
+	frequency map[string]int
+	ignored   map[string]bool
+
+	MaxDistance    int
+	MaxSuggestions int
+}
+
+// Misspelling is a word the checker didn't know. Offset is where it starts in the text, in bytes.
+type Misspelling struct {
+	Word        string   `json:"word"`
+	Offset      int      `json:"offset"`
+	Suggestions []string `json:"suggestions"`
+}
+
+func NewSpellChecker(words Dictionary) *SpellChecker {
+	s := &SpellChecker{
+		words:          map[string]bool{},
+		index:          newTrieNode(),
+		frequency:      map[string]int{},
+		ignored:        map[string]bool{},
+		MaxDistance:    DefaultMaxDistance,
+		MaxSuggestions: DefaultMaxSuggestions,
+	}
+	for word := range words {
+		s.words[strings.ToLower(word)] = true
+		s.index.insert(strings.ToLower(word))
+	}
+	// every word has to be known before any are counted, or the counts would depend on map order
+	for _, definition := range words {
+		s.Learn(definition)
+	}
+	return s
+}
+
+// Learn counts how often the words in text are used, to rank suggestions by.
+func (s *SpellChecker) Learn(text string) {
+	for _, token := range spellTokens(text) {
+		if word := strings.ToLower(token.word); s.known(word) {
+			s.frequency[word]++
+		}
+	}
+}
+
+// Ignore stops words being flagged, whatever their case, without adding them to the word list,
+// which is handy for names and jargon.
+func (s *SpellChecker) Ignore(words ...string) {
+	for _, word := range words {
+		s.ignored[strings.ToLower(word)] = true
+	}
+}
+
+// Check returns every word in text that isn't in the word list or ignored, in the order they appear.
+func (s *SpellChecker) Check(text string) []Misspelling {
+	var misspellings []Misspelling
+	for _, token := range spellTokens(text) {
+		if s.correct(token.word) {
+			continue
+		}
+		misspellings = append(misspellings, Misspelling{
+			Word:        token.word,
+			Offset:      token.offset,
+			Suggestions: s.Suggest(token.word),
+		})
+	}
+	return misspellings
+}
+
+// Suggest ranks the words within MaxDistance edits, closest first, then most common first.
+// If the word starts with a capital, like at the start of a sentence, so do the suggestions.
+func (s *SpellChecker) Suggest(word string) []string {
+	lower := strings.ToLower(word)
+	found := s.index.withinDistance(lower, s.MaxDistance)
+
+	slices.SortStableFunc(found, func(a, b suggestion) int {
+		if a.distance != b.distance {
+			return a.distance - b.distance
+		}
+		return s.frequency[b.word] - s.frequency[a.word]
+	})
+
+	first, _ := utf8.DecodeRuneInString(word)
+	capitalise := unicode.IsUpper(first)
+
+	suggestions := []string{}
+	for _, f := range found {
+		if f.word == lower {
+			continue
+		}
+		if len(suggestions) == s.MaxSuggestions {
+			break
+		}
+		if capitalise {
+			r, size := utf8.DecodeRuneInString(f.word)
+			f.word = string(unicode.ToUpper(r)) + f.word[size:]
+		}
+		suggestions = append(suggestions, f.word)
+	}
+	return suggestions
+}
+
+// correct accepts a known word in any case, so "The" is fine at the start of a sentence,
+// and possessives of known words, like "dictionary's".
+func (s *SpellChecker) correct(word string) bool {
+	lower := strings.ToLower(word)
+	if s.ignored[lower] || s.known(lower) {
+		return true
+	}
+	if base, ok := strings.CutSuffix(lower, "'s"); ok {
+		return s.ignored[base] || s.known(base)
+	}
+	return false
+}
+
+// known expects word in lower case.
+func (s *SpellChecker) known(word string) bool {
+	return s.words[word]
+}
+
+type spellToken struct {
+	word   string
+	offset int
+}
+
+// spellTokens finds the words in text: runs of letters, with apostrophes allowed
+// between letters so "don't" is one word. Anything with a digit in it, like "x86" or "utf8",
+// is skipped, as it's almost certainly not meant to be an English word.
+func spellTokens(text string) []spellToken {
+	var tokens []spellToken
+
+	start := -1
+	end := func(at int) {
+		if start >= 0 {
+			word := text[start:at]
+			if strings.IndexFunc(word, unicode.IsDigit) == -1 {
+				tokens = append(tokens, spellToken{word: word, offset: start})
+			}
+		}
+		start = -1
+	}
+
+	for i, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if start < 0 {
+				start = i
+			}
+		case (r == '\'' || r == '’') && start >= 0:
+			next, _ := utf8.DecodeRuneInString(text[i+utf8.RuneLen(r):])
+			if !unicode.IsLetter(next) {
+				end(i)
+			}
+		default:
+			end(i)
+		}
+	}
+	end(len(text))
+
+	return tokens
+}
+
+const (
+	ErrMisspelled = DictionaryErr("found misspelled words")
+	ErrNoWordList = DictionaryErr("a word list is needed, pass one with -words")
+)
+
+// RunSpellCLI checks the text from in and prints a line for every misspelling to out:
+// its byte offset, the word and any suggestions, separated by tabs so scripts can pick them apart.
+// It returns ErrMisspelled if it found anything, so a docs pipeline can fail the build.
+func RunSpellCLI(args []string, in io.Reader, out, errOut io.Writer) error {
+	flags := flag.NewFlagSet("spell", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	wordList := flags.String("words", "", "dictionary to use as the word list, a .json, .csv or .tsv file")
+	ignoreList := flags.String("ignore", "", "file of words to ignore, one per line")
+	maxDistance := flags.Int("max-distance", DefaultMaxDistance, "how many edits away a suggestion can be")
+	maxSuggestions := flags.Int("suggestions", DefaultMaxSuggestions, "how many suggestions to show per word")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *wordList == "" {
+		return ErrNoWordList
+	}
+	format, err := FormatFromPath(*wordList)
+	if err != nil {
+		return err
+	}
+	words, err := Load(*wordList, format)
+	if err != nil {
+		return err
+	}
+
+	checker := NewSpellChecker(words)
+	checker.MaxDistance = *maxDistance
+	checker.MaxSuggestions = *maxSuggestions
+
+	if *ignoreList != "" {
+		ignored, err := readWordList(*ignoreList)
+		if err != nil {
+			return err
+		}
+		checker.Ignore(ignored...)
+	}
+
+	text, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+
+	misspellings := checker.Check(string(text))
+	for _, m := range misspellings {
+		fmt.Fprintf(out, "%d\t%s\t%s\n", m.Offset, m.Word, strings.Join(m.Suggestions, ","))
+	}
+	if len(misspellings) > 0 {
+		return ErrMisspelled
+	}
+	return nil
+}
+
+// readWordList reads one word per line, skipping blank lines and # comments.
+func readWordList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	return words, scanner.Err()
+}
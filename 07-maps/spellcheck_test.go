@@ -0,0 +1,175 @@
+package dictionary
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var spellingWords = Dictionary{
+	"the":        "used before a noun, like the cat or the tea",
+	"tea":        "a drink made by soaking leaves in hot water",
+	"cat":        "a small furry animal",
+	"sat":        "past tense of sit",
+	"on":         "resting on top of something",
+	"mat":        "a small rug",
+	"dictionary": "a book of words and what they mean",
+	"don't":      "do not",
+}
+
+func TestSpellChecker(t *testing.T) {
+	t.Run("flags unknown words with their byte offsets", func(t *testing.T) {
+		checker := NewSpellChecker(spellingWords)
+
+		got := checker.Check("The cat szt on teh mat")
+
+		assertMisspellings(t, got, []Misspelling{
+			{Word: "szt", Offset: 8, Suggestions: []string{"sat", "cat", "mat"}},
+			{Word: "teh", Offset: 15, Suggestions: []string{"the", "tea"}},
+		})
+	})
+
+	t.Run("offsets count bytes, not letters", func(t *testing.T) {
+		checker := NewSpellChecker(spellingWords)
+
+		got := checker.Check("café cat")
+
+		if len(got) != 1 || got[0].Word != "café" || got[0].Offset != 0 {
+			t.Fatalf("got %+v", got)
+		}
+		got = checker.Check("café catt")
+		// é takes two bytes, so catt starts at byte 6
+		if got[1].Offset != 6 || !strings.HasPrefix("café catt"[got[1].Offset:], "catt") {
+			t.Errorf("got %+v", got[1])
+		}
+	})
+
+	t.Run("ranks equally close suggestions by how common they are", func(t *testing.T) {
+		checker := NewSpellChecker(spellingWords)
+		checker.MaxDistance = 1
+
+		// "the" and "tea" are both used in definitions, but "the" more often
+		assertWords(t, checker.Suggest("teh"), "the", "tea")
+
+		checker.Learn("tea tea tea tea")
+		assertWords(t, checker.Suggest("teh"), "tea", "the")
+	})
+
+	t.Run("keeps capitals for suggestions", func(t *testing.T) {
+		checker := NewSpellChecker(spellingWords)
+		checker.MaxDistance = 1
+
+		assertWords(t, checker.Suggest("Teh"), "The", "Tea")
+	})
+
+	t.Run("limits suggestions", func(t *testing.T) {
+		checker := NewSpellChecker(spellingWords)
+		checker.MaxSuggestions = 1
+
+		assertWords(t, checker.Suggest("teh"), "the")
+	})
+
+	t.Run("accepts capitals, apostrophes and possessives", func(t *testing.T) {
+		checker := NewSpellChecker(spellingWords)
+
+		assertMisspellings(t, checker.Check("The CAT sat on the dictionary's mat, don't 'tea'"), nil)
+	})
+
+	t.Run("capitalised headwords match in any case", func(t *testing.T) {
+		checker := NewSpellChecker(Dictionary{
+			"Go":     "a programming language, see go",
+			"go":     "to move",
+			"Gopher": "the Go mascot",
+		})
+
+		assertMisspellings(t, checker.Check("go GO Go gopher GOPHER"), nil)
+		if got := checker.frequency["gopher"]; got != 0 {
+			t.Errorf("gopher is not used in any definition, got a count of %d", got)
+		}
+		if got := checker.frequency["go"]; got != 2 {
+			t.Errorf("go is used twice in the definitions, got a count of %d", got)
+		}
+
+		checker = NewSpellChecker(Dictionary{"Go": "a programming language"})
+		assertMisspellings(t, checker.Check("go GO"), nil)
+		checker.Learn("Go go GO")
+		if got := checker.frequency["go"]; got != 3 {
+			t.Errorf("got a count of %d want 3", got)
+		}
+	})
+
+	t.Run("skips words with digits", func(t *testing.T) {
+		checker := NewSpellChecker(spellingWords)
+
+		assertMisspellings(t, checker.Check("the x86 utf8 cat"), nil)
+	})
+
+	t.Run("ignore list", func(t *testing.T) {
+		checker := NewSpellChecker(spellingWords)
+		checker.Ignore("Gopher", "go")
+
+		assertMisspellings(t, checker.Check("the gopher sat on go's mat"), nil)
+	})
+
+	t.Run("empty suggestions rather than nil", func(t *testing.T) {
+		checker := NewSpellChecker(spellingWords)
+
+		got := checker.Check("zzzzzzzz")
+		if got[0].Suggestions == nil {
+			t.Error("want an empty list so it encodes as [] in JSON")
+		}
+	})
+}
+
+func TestSpellTokens(t *testing.T) {
+	got := spellTokens("Don’t stop, it's 'quoted' x86!")
+	want := []spellToken{{"Don’t", 0}, {"stop", 8}, {"it's", 14}, {"quoted", 20}}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestRunSpellCLI(t *testing.T) {
+	dir := t.TempDir()
+	wordList := filepath.Join(dir, "words.json")
+	spellingWords.Save(wordList, JSON)
+	ignoreList := filepath.Join(dir, "ignore.txt")
+	os.WriteFile(ignoreList, []byte("# names\nGopher\n\n"), 0o644)
+
+	t.Run("prints misspellings", func(t *testing.T) {
+		var out, errOut strings.Builder
+
+		err := RunSpellCLI([]string{"-words", wordList, "-suggestions", "2"}, strings.NewReader("the cat szt\nteh Gopher"), &out, &errOut)
+
+		assertError(t, err, ErrMisspelled)
+		assertStrings(t, out.String(), "8\tszt\tsat,cat\n12\tteh\tthe,tea\n16\tGopher\t\n")
+	})
+
+	t.Run("ignore list", func(t *testing.T) {
+		var out, errOut strings.Builder
+
+		err := RunSpellCLI([]string{"-words", wordList, "-ignore", ignoreList}, strings.NewReader("the gopher sat"), &out, &errOut)
+
+		assertError(t, err, nil)
+		assertStrings(t, out.String(), "")
+	})
+
+	t.Run("needs a word list", func(t *testing.T) {
+		var out, errOut strings.Builder
+
+		err := RunSpellCLI(nil, strings.NewReader(""), &out, &errOut)
+
+		assertError(t, err, ErrNoWordList)
+	})
+}
+
+func assertMisspellings(t testing.TB, got, want []Misspelling) {
+	t.Helper()
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v want %+v", got, want)
+	}
+}
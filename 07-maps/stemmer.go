@@ -0,0 +1,215 @@
+package dictionary
+
+import "strings"
+
+// Stem reduces an English word to its stem with Martin Porter's 1980 algorithm,
+// so "connect", "connected", "connecting" and "connections" all become "connect".
+// The stem isn't always a real word ("happy" becomes "happi"), but it doesn't need to be:
+// it only has to be the same for words that mean the same thing.
+//
+// Stem expects a lower case word and leaves anything that isn't plain a-z alone.
+//
+// The algorithm is described at https://tartarus.org/martin/PorterStemmer/def.txt
+// and the comments below use its notation: m is the measure of a stem, the number
+// of vowel-consonant sequences in it, *v* means the stem contains a vowel,
+// *d that it ends in a double consonant, and *o that it ends consonant-vowel-consonant
+// where the last consonant isn't w, x or y.
+func Stem(word string) string {
+	if len(word) <= 2 || strings.IndexFunc(word, func(r rune) bool { return r < 'a' || r > 'z' }) != -1 {
+		return word
+	}
+
+	w := stemmer(word)
+	w = w.step1a()
+	w = w.step1b()
+	w = w.step1c()
+	w = w.step2()
+	w = w.step3()
+	w = w.step4()
+	w = w.step5()
+	return string(w)
+}
+
+type stemmer string
+
+// consonant says whether the letter at i is a consonant. Y is a consonant
+// at the start of a word or after a vowel, and a vowel after a consonant, like in "sky".
+func (w stemmer) consonant(i int) bool {
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !w.consonant(i-1)
+	default:
+		return true
+	}
+}
+
+// measure is m, the number of times a vowel is followed by a consonant.
+func (w stemmer) measure() int {
+	m := 0
+	for i := 1; i < len(w); i++ {
+		if !w.consonant(i-1) && w.consonant(i) {
+			m++
+		}
+	}
+	return m
+}
+
+func (w stemmer) hasVowel() bool {
+	for i := range len(w) {
+		if !w.consonant(i) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w stemmer) endsDoubleConsonant() bool {
+	n := len(w)
+	return n >= 2 && w[n-1] == w[n-2] && w.consonant(n-1)
+}
+
+func (w stemmer) endsCVC() bool {
+	n := len(w)
+	if n < 3 || !w.consonant(n-3) || w.consonant(n-2) || !w.consonant(n-1) {
+		return false
+	}
+	return w[n-1] != 'w' && w[n-1] != 'x' && w[n-1] != 'y'
+}
+
+type suffixRule struct {
+	suffix, replacement string
+}
+
+// replaceLongest finds the longest suffix in rules that the word ends with, and replaces it if the
+// stem left over passes the condition. Only the longest match counts: if its condition fails,
+// shorter suffixes aren't tried. The bool reports whether any suffix matched.
+func (w stemmer) replaceLongest(rules []suffixRule, condition func(stem stemmer) bool) (stemmer, bool) {
+	longest := -1
+	for i, rule := range rules {
+		if strings.HasSuffix(string(w), rule.suffix) && (longest == -1 || len(rule.suffix) > len(rules[longest].suffix)) {
+			longest = i
+		}
+	}
+	if longest == -1 {
+		return w, false
+	}
+
+	rule := rules[longest]
+	stem := w[:len(w)-len(rule.suffix)]
+	if condition(stem) {
+		return stem + stemmer(rule.replacement), true
+	}
+	return w, true
+}
+
+func measureAbove(n int) func(stemmer) bool {
+	return func(stem stemmer) bool { return stem.measure() > n }
+}
+
+// step1a deals with plurals: caresses -> caress, ponies -> poni, cats -> cat.
+func (w stemmer) step1a() stemmer {
+	w, _ = w.replaceLongest([]suffixRule{{"sses", "ss"}, {"ies", "i"}, {"ss", "ss"}, {"s", ""}}, func(stemmer) bool { return true })
+	return w
+}
+
+// step1b deals with -ed and -ing: feed -> feed, agreed -> agree, plastered -> plaster, motoring -> motor.
+func (w stemmer) step1b() stemmer {
+	if strings.HasSuffix(string(w), "eed") {
+		if stem := w[:len(w)-3]; stem.measure() > 0 {
+			return stem + "ee"
+		}
+		return w
+	}
+
+	var stem stemmer
+	switch {
+	case strings.HasSuffix(string(w), "ed") && w[:len(w)-2].hasVowel():
+		stem = w[:len(w)-2]
+	case strings.HasSuffix(string(w), "ing") && w[:len(w)-3].hasVowel():
+		stem = w[:len(w)-3]
+	default:
+		return w
+	}
+
+	// tidy up what's left so conflated -> conflate, hopping -> hop and filing -> file
+	switch {
+	case strings.HasSuffix(string(stem), "at"), strings.HasSuffix(string(stem), "bl"), strings.HasSuffix(string(stem), "iz"):
+		return stem + "e"
+	case stem.endsDoubleConsonant() && !strings.ContainsAny(string(stem[len(stem)-1:]), "lsz"):
+		return stem[:len(stem)-1]
+	case stem.measure() == 1 && stem.endsCVC():
+		return stem + "e"
+	default:
+		return stem
+	}
+}
+
+// step1c: happy -> happi, but sky stays as it is.
+func (w stemmer) step1c() stemmer {
+	if strings.HasSuffix(string(w), "y") && w[:len(w)-1].hasVowel() {
+		return w[:len(w)-1] + "i"
+	}
+	return w
+}
+
+var step2Rules = []suffixRule{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+	{"izer", "ize"}, {"abli", "able"}, {"alli", "al"}, {"entli", "ent"},
+	{"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"},
+	{"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+	{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+}
+
+// step2 turns double suffixes into single ones: relational -> relate, digitizer -> digitize.
+func (w stemmer) step2() stemmer {
+	w, _ = w.replaceLongest(step2Rules, measureAbove(0))
+	return w
+}
+
+var step3Rules = []suffixRule{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+	{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+}
+
+// step3: triplicate -> triplic, hopeful -> hope, goodness -> good.
+func (w stemmer) step3() stemmer {
+	w, _ = w.replaceLongest(step3Rules, measureAbove(0))
+	return w
+}
+
+var step4Rules = []suffixRule{
+	{"al", ""}, {"ance", ""}, {"ence", ""}, {"er", ""}, {"ic", ""}, {"able", ""},
+	{"ible", ""}, {"ant", ""}, {"ement", ""}, {"ment", ""}, {"ent", ""}, {"ion", ""},
+	{"ou", ""}, {"ism", ""}, {"ate", ""}, {"iti", ""}, {"ous", ""}, {"ive", ""}, {"ize", ""},
+}
+
+// step4 takes off the last suffix from longer words: revival -> reviv, adjustment -> adjust.
+func (w stemmer) step4() stemmer {
+	w, _ = w.replaceLongest(step4Rules, func(stem stemmer) bool {
+		if stem.measure() <= 1 {
+			return false
+		}
+		// -ion only comes off after s or t, so adoption -> adopt but not onion -> on
+		if strings.HasSuffix(string(w), "ion") {
+			return strings.HasSuffix(string(stem), "s") || strings.HasSuffix(string(stem), "t")
+		}
+		return true
+	})
+	return w
+}
+
+// step5 removes a final e and a double l: probate -> probat, rate -> rate, controll -> control.
+func (w stemmer) step5() stemmer {
+	if strings.HasSuffix(string(w), "e") {
+		stem := w[:len(w)-1]
+		if m := stem.measure(); m > 1 || (m == 1 && !stem.endsCVC()) {
+			w = stem
+		}
+	}
+	if w.measure() > 1 && w.endsDoubleConsonant() && strings.HasSuffix(string(w), "l") {
+		w = w[:len(w)-1]
+	}
+	return w
+}
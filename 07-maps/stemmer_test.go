@@ -0,0 +1,41 @@
+package dictionary
+
+import "testing"
+
+func TestStem(t *testing.T) {
+	// the examples from Porter's paper, run through the whole algorithm rather than a single step
+	cases := map[string]string{
+		"caresses": "caress", "ponies": "poni", "ties": "ti", "caress": "caress", "cats": "cat",
+		"feed": "feed", "agreed": "agre", "plastered": "plaster", "bled": "bled", "motoring": "motor",
+		"sing": "sing", "conflated": "conflat", "troubled": "troubl", "sized": "size", "hopping": "hop",
+		"tanned": "tan", "falling": "fall", "hissing": "hiss", "fizzed": "fizz", "failing": "fail",
+		"filing": "file", "happy": "happi", "sky": "sky",
+		"relational": "relat", "conditional": "condit", "rational": "ration", "valenci": "valenc",
+		"digitizer": "digit", "conformabli": "conform", "radicalli": "radic", "differentli": "differ",
+		"vileli": "vile", "analogousli": "analog", "vietnamization": "vietnam", "predication": "predic",
+		"operator": "oper", "feudalism": "feudal", "decisiveness": "decis", "hopefulness": "hope",
+		"callousness": "callous", "formaliti": "formal", "sensitiviti": "sensit", "sensibiliti": "sensibl",
+		"triplicate": "triplic", "formative": "form", "formalize": "formal", "electriciti": "electr",
+		"electrical": "electr", "hopeful": "hope", "goodness": "good",
+		"revival": "reviv", "allowance": "allow", "inference": "infer", "airliner": "airlin",
+		"gyroscopic": "gyroscop", "adjustable": "adjust", "defensible": "defens", "irritant": "irrit",
+		"replacement": "replac", "adjustment": "adjust", "dependent": "depend", "adoption": "adopt",
+		"homologou": "homolog", "communism": "commun", "activate": "activ", "angulariti": "angular",
+		"homologous": "homolog", "effective": "effect", "bowdlerize": "bowdler",
+		"probate": "probat", "rate": "rate", "cease": "ceas", "controll": "control", "roll": "roll",
+		"generalizations": "gener", "oscillators": "oscil",
+		"connect": "connect", "connected": "connect", "connecting": "connect", "connection": "connect", "connections": "connect",
+	}
+
+	for word, want := range cases {
+		if got := Stem(word); got != want {
+			t.Errorf("Stem(%q) got %q want %q", word, got, want)
+		}
+	}
+
+	t.Run("leaves short and non-ascii words alone", func(t *testing.T) {
+		for _, word := range []string{"is", "a", "café", "Running", "x86"} {
+			assertStrings(t, Stem(word), word)
+		}
+	})
+}
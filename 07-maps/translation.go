@@ -0,0 +1,181 @@
+package dictionary
+
+import (
+	"fmt"
+	"slices"
+)
+
+// TranslationDictionary maps words in one language to their translations in another.
+// A word can have several translations, and a translation can come from several words,
+// so as well as the map from source words we keep a reverse index from target words,
+// which lets you look words up in either direction without searching every entry.
+
+// Language is a language code like "en" or "de".
+type Language string
+
+const (
+	English = Language("en")
+	German  = Language("de")
+	Spanish = Language("es")
+)
+
+const (
+	ErrNoTranslations      = DictionaryErr("a word needs at least one translation")
+	ErrTranslationExists   = DictionaryErr("the word already has that translation")
+	ErrUnsupportedLanguage = DictionaryErr("the dictionary doesn't translate that language")
+)
+
+type TranslationDictionary struct {
+	Source Language
+	Target Language
+
+	forward map[string][]string
+	reverse map[string][]string
+}
+
+func NewTranslationDictionary(source, target Language) *TranslationDictionary {
+	return &TranslationDictionary{
+		Source:  source,
+		Target:  target,
+		forward: map[string][]string{},
+		reverse: map[string][]string{},
+	}
+}
+
+// Translate looks a source language word up. The translations come back in the order they were added.
+func (d *TranslationDictionary) Translate(word string) ([]string, error) {
+	return lookup(d.forward, word)
+}
+
+// TranslateBack looks a target language word up, giving you every source word that translates to it.
+func (d *TranslationDictionary) TranslateBack(word string) ([]string, error) {
+	return lookup(d.reverse, word)
+}
+
+// Lookup translates from either language, so an en<->de dictionary can be used both ways.
+func (d *TranslationDictionary) Lookup(from Language, word string) ([]string, error) {
+	switch from {
+	case d.Source:
+		return d.Translate(word)
+	case d.Target:
+		return d.TranslateBack(word)
+	default:
+		return nil, fmt.Errorf("%w: %s, want %s or %s", ErrUnsupportedLanguage, from, d.Source, d.Target)
+	}
+}
+
+func (d *TranslationDictionary) Add(word string, translations ...string) error {
+	if _, ok := d.forward[word]; ok {
+		return ErrWordExists
+	}
+	translations = compactTranslations(translations)
+	if len(translations) == 0 {
+		return ErrNoTranslations
+	}
+
+	d.forward[word] = translations
+	for _, translation := range translations {
+		d.reverse[translation] = append(d.reverse[translation], word)
+	}
+	return nil
+}
+
+// AddTranslation gives a word that's already in the dictionary one more translation.
+func (d *TranslationDictionary) AddTranslation(word, translation string) error {
+	translations, ok := d.forward[word]
+	if !ok {
+		return ErrWordDoesNotExist
+	}
+	if translation == "" {
+		return ErrNoTranslations
+	}
+	if slices.Contains(translations, translation) {
+		return ErrTranslationExists
+	}
+
+	d.forward[word] = append(slices.Clip(translations), translation)
+	d.reverse[translation] = append(d.reverse[translation], word)
+	return nil
+}
+
+// Update replaces all of a word's translations.
+func (d *TranslationDictionary) Update(word string, translations ...string) error {
+	if _, ok := d.forward[word]; !ok {
+		return ErrWordDoesNotExist
+	}
+	translations = compactTranslations(translations)
+	if len(translations) == 0 {
+		return ErrNoTranslations
+	}
+
+	d.removeReverse(word)
+	d.forward[word] = translations
+	for _, translation := range translations {
+		d.reverse[translation] = append(d.reverse[translation], word)
+	}
+	return nil
+}
+
+func (d *TranslationDictionary) Delete(word string) error {
+	if _, ok := d.forward[word]; !ok {
+		return ErrWordDoesNotExist
+	}
+	d.removeReverse(word)
+	delete(d.forward, word)
+	return nil
+}
+
+// Words lists the source language words, sorted.
+func (d *TranslationDictionary) Words() []string {
+	words := make([]string, 0, len(d.forward))
+	for word := range d.forward {
+		words = append(words, word)
+	}
+	slices.Sort(words)
+	return words
+}
+
+// Reverse returns a new dictionary going the other way, so an en->de dictionary gives you a de->en one.
+func (d *TranslationDictionary) Reverse() *TranslationDictionary {
+	reversed := NewTranslationDictionary(d.Target, d.Source)
+	for translation, words := range d.reverse {
+		reversed.forward[translation] = slices.Clone(words)
+	}
+	for word, translations := range d.forward {
+		reversed.reverse[word] = slices.Clone(translations)
+	}
+	return reversed
+}
+
+// removeReverse takes word out of the reverse index entry of each of its translations,
+// and drops entries that no longer have any words, so TranslateBack reports them as not found.
+func (d *TranslationDictionary) removeReverse(word string) {
+	for _, translation := range d.forward[word] {
+		words := slices.DeleteFunc(slices.Clone(d.reverse[translation]), func(w string) bool { return w == word })
+		if len(words) == 0 {
+			delete(d.reverse, translation)
+			continue
+		}
+		d.reverse[translation] = words
+	}
+}
+
+// lookup hands out a copy so callers can't change the index by appending to what they got back.
+func lookup(index map[string][]string, word string) ([]string, error) {
+	translations, ok := index[word]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return slices.Clone(translations), nil
+}
+
+// compactTranslations drops empty and repeated translations, keeping the order they were given in.
+func compactTranslations(translations []string) []string {
+	var compacted []string
+	for _, translation := range translations {
+		if translation != "" && !slices.Contains(compacted, translation) {
+			compacted = append(compacted, translation)
+		}
+	}
+	return compacted
+}
@@ -0,0 +1,145 @@
+package dictionary
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTranslationDictionary(t *testing.T) {
+	newEnglishGerman := func() *TranslationDictionary {
+		dictionary := NewTranslationDictionary(English, German)
+		dictionary.Add("house", "Haus")
+		dictionary.Add("home", "Zuhause", "Heim", "Haus")
+		return dictionary
+	}
+
+	t.Run("translates both ways", func(t *testing.T) {
+		dictionary := newEnglishGerman()
+
+		assertTranslations(t, dictionary.Translate, "home", "Zuhause", "Heim", "Haus")
+		assertTranslations(t, dictionary.TranslateBack, "Haus", "house", "home")
+		assertTranslations(t, dictionary.TranslateBack, "Heim", "home")
+	})
+
+	t.Run("lookup by language", func(t *testing.T) {
+		dictionary := newEnglishGerman()
+
+		got, err := dictionary.Lookup(English, "house")
+		assertError(t, err, nil)
+		assertWords(t, got, "Haus")
+
+		got, err = dictionary.Lookup(German, "Haus")
+		assertError(t, err, nil)
+		assertWords(t, got, "house", "home")
+
+		_, err = dictionary.Lookup(Spanish, "casa")
+		if !errors.Is(err, ErrUnsupportedLanguage) {
+			t.Errorf("got %v want %v", err, ErrUnsupportedLanguage)
+		}
+	})
+
+	t.Run("same errors as Dictionary", func(t *testing.T) {
+		dictionary := newEnglishGerman()
+
+		_, err := dictionary.Translate("Haus")
+		assertError(t, err, ErrNotFound)
+		_, err = dictionary.TranslateBack("house")
+		assertError(t, err, ErrNotFound)
+
+		assertError(t, dictionary.Add("house", "Gebäude"), ErrWordExists)
+		assertError(t, dictionary.Update("car", "Auto"), ErrWordDoesNotExist)
+		assertError(t, dictionary.Delete("car"), ErrWordDoesNotExist)
+		assertError(t, dictionary.AddTranslation("car", "Auto"), ErrWordDoesNotExist)
+	})
+
+	t.Run("needs translations", func(t *testing.T) {
+		dictionary := newEnglishGerman()
+
+		assertError(t, dictionary.Add("car"), ErrNoTranslations)
+		assertError(t, dictionary.Add("car", ""), ErrNoTranslations)
+		assertError(t, dictionary.Update("house"), ErrNoTranslations)
+		assertError(t, dictionary.AddTranslation("house", ""), ErrNoTranslations)
+	})
+
+	t.Run("repeated translations are only kept once", func(t *testing.T) {
+		dictionary := NewTranslationDictionary(English, Spanish)
+
+		dictionary.Add("house", "casa", "casa", "vivienda")
+
+		assertTranslations(t, dictionary.Translate, "house", "casa", "vivienda")
+		assertTranslations(t, dictionary.TranslateBack, "casa", "house")
+		assertError(t, dictionary.AddTranslation("house", "casa"), ErrTranslationExists)
+	})
+
+	t.Run("add a translation", func(t *testing.T) {
+		dictionary := newEnglishGerman()
+
+		assertError(t, dictionary.AddTranslation("house", "Gebäude"), nil)
+
+		assertTranslations(t, dictionary.Translate, "house", "Haus", "Gebäude")
+		assertTranslations(t, dictionary.TranslateBack, "Gebäude", "house")
+	})
+
+	t.Run("update keeps the reverse index in sync", func(t *testing.T) {
+		dictionary := newEnglishGerman()
+
+		assertError(t, dictionary.Update("home", "Zuhause", "Heimat"), nil)
+
+		assertTranslations(t, dictionary.Translate, "home", "Zuhause", "Heimat")
+		assertTranslations(t, dictionary.TranslateBack, "Haus", "house")
+		assertTranslations(t, dictionary.TranslateBack, "Heimat", "home")
+		_, err := dictionary.TranslateBack("Heim")
+		assertError(t, err, ErrNotFound)
+	})
+
+	t.Run("delete keeps the reverse index in sync", func(t *testing.T) {
+		dictionary := newEnglishGerman()
+
+		assertError(t, dictionary.Delete("home"), nil)
+
+		assertTranslations(t, dictionary.TranslateBack, "Haus", "house")
+		for _, word := range []string{"Zuhause", "Heim"} {
+			_, err := dictionary.TranslateBack(word)
+			assertError(t, err, ErrNotFound)
+		}
+		assertWords(t, dictionary.Words(), "house")
+	})
+
+	t.Run("results are copies", func(t *testing.T) {
+		dictionary := newEnglishGerman()
+
+		got, _ := dictionary.Translate("home")
+		got[0] = "changed"
+		got, _ = dictionary.TranslateBack("Haus")
+		got[0] = "changed"
+
+		assertTranslations(t, dictionary.Translate, "home", "Zuhause", "Heim", "Haus")
+		assertTranslations(t, dictionary.TranslateBack, "Haus", "house", "home")
+	})
+
+	t.Run("reverse", func(t *testing.T) {
+		dictionary := newEnglishGerman()
+
+		germanEnglish := dictionary.Reverse()
+
+		if germanEnglish.Source != German || germanEnglish.Target != English {
+			t.Errorf("got %s->%s want de->en", germanEnglish.Source, germanEnglish.Target)
+		}
+		assertTranslations(t, germanEnglish.Translate, "Haus", "house", "home")
+		assertTranslations(t, germanEnglish.TranslateBack, "home", "Zuhause", "Heim", "Haus")
+
+		// the reversed dictionary is separate from the original
+		germanEnglish.Delete("Haus")
+		assertTranslations(t, dictionary.TranslateBack, "Haus", "house", "home")
+	})
+}
+
+func assertTranslations(t testing.TB, translate func(string) ([]string, error), word string, want ...string) {
+	t.Helper()
+
+	got, err := translate(word)
+	if err != nil {
+		t.Fatalf("could not translate %q: %v", word, err)
+	}
+	assertWords(t, got, want...)
+}
@@ -0,0 +1,358 @@
+package dictionary
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VersionedDictionary never forgets a definition. Every Add, Update and Delete appends a Revision
+// to the word's history, saying who made the change and when, so a definition someone else
+// overwrote can be found and brought back.
+//
+// To stop editors overwriting each other in the first place, Update takes the version the editor
+// last saw. If somebody else has changed the word since, the update is refused with a
+// VersionConflictError instead of quietly throwing their change away.
+
+// Clock is how the dictionary knows when a change was made, so tests can control time.
+type Clock interface {
+	Now() time.Time
+}
+
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+type ChangeKind string
+
+const (
+	ChangeAdd    = ChangeKind("add")
+	ChangeUpdate = ChangeKind("update")
+	ChangeDelete = ChangeKind("delete")
+	ChangeRevert = ChangeKind("revert")
+)
+
+// Revision is one version of a word. Versions count up from 1 and carry on
+// if a word is deleted and added again, so a version number is never reused.
+type Revision struct {
+	Version    int        `json:"version"`
+	Change     ChangeKind `json:"change"`
+	Definition string     `json:"definition"`
+	Author     string     `json:"author"`
+	Time       time.Time  `json:"time"`
+	// Deleted is true when the word didn't exist after this revision,
+	// which is the case after a delete or after reverting to one.
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// AnyVersion can be passed to Update to skip the version check.
+const AnyVersion = 0
+
+const (
+	ErrVersionConflict  = DictionaryErr("the word has been changed since you last saw it")
+	ErrVersionNotFound  = DictionaryErr("the word has no such version")
+	ErrNothingToUndo    = DictionaryErr("the word has no earlier version to go back to")
+	ErrAlreadyAtVersion = DictionaryErr("the word already has that definition")
+)
+
+// VersionConflictError says which version you expected and which one the word is actually at.
+// It counts as ErrVersionConflict for errors.Is.
+type VersionConflictError struct {
+	Word     string
+	Expected int
+	Actual   int
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%v: %q is at version %d, not %d", ErrVersionConflict, e.Word, e.Actual, e.Expected)
+}
+
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
+
+type VersionedDictionary struct {
+	mu      sync.RWMutex
+	clock   Clock
+	history map[string][]Revision
+}
+
+func NewVersionedDictionary(clock Clock) *VersionedDictionary {
+	if clock == nil {
+		clock = ClockFunc(time.Now)
+	}
+	return &VersionedDictionary{clock: clock, history: map[string][]Revision{}}
+}
+
+func (d *VersionedDictionary) Search(word string) (string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	latest, ok := d.latest(word)
+	if !ok || latest.Deleted {
+		return "", ErrNotFound
+	}
+	return latest.Definition, nil
+}
+
+// Version returns the word's current version, to pass back to Update later.
+func (d *VersionedDictionary) Version(word string) (int, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	latest, ok := d.latest(word)
+	if !ok || latest.Deleted {
+		return 0, ErrNotFound
+	}
+	return latest.Version, nil
+}
+
+// Add works for words that were deleted as well as brand new ones.
+func (d *VersionedDictionary) Add(author, word, definition string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if latest, ok := d.latest(word); ok && !latest.Deleted {
+		return ErrWordExists
+	}
+	d.record(word, Revision{Change: ChangeAdd, Definition: definition, Author: author})
+	return nil
+}
+
+// Update only succeeds if the word is still at expectedVersion, or expectedVersion is AnyVersion.
+func (d *VersionedDictionary) Update(author, word, definition string, expectedVersion int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	latest, ok := d.latest(word)
+	if !ok || latest.Deleted {
+		return ErrWordDoesNotExist
+	}
+	if expectedVersion != AnyVersion && expectedVersion != latest.Version {
+		return &VersionConflictError{Word: word, Expected: expectedVersion, Actual: latest.Version}
+	}
+	d.record(word, Revision{Change: ChangeUpdate, Definition: definition, Author: author})
+	return nil
+}
+
+// Delete keeps the history, so the word can still be reverted to an earlier version.
+func (d *VersionedDictionary) Delete(author, word string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	latest, ok := d.latest(word)
+	if !ok || latest.Deleted {
+		return ErrWordDoesNotExist
+	}
+	d.record(word, Revision{Change: ChangeDelete, Author: author, Deleted: true})
+	return nil
+}
+
+// Words lists the words that currently exist, sorted. Deleted words are left out even though their history is kept.
+func (d *VersionedDictionary) Words() []string {
+	return d.Dictionary().Words()
+}
+
+// Dictionary is a snapshot of the current definitions, for saving or anything else that wants a plain Dictionary.
+func (d *VersionedDictionary) Dictionary() Dictionary {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	dictionary := Dictionary{}
+	for word := range d.history {
+		if latest, _ := d.latest(word); !latest.Deleted {
+			dictionary[word] = latest.Definition
+		}
+	}
+	return dictionary
+}
+
+// History returns every revision of the word, oldest first.
+func (d *VersionedDictionary) History(word string) ([]Revision, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	revisions, ok := d.history[word]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]Revision(nil), revisions...), nil
+}
+
+func (d *VersionedDictionary) Revision(word string, version int) (Revision, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.revision(word, version)
+}
+
+// Revert makes a new revision with the definition the word had at version.
+// Reverting to a version where the word was deleted deletes it again.
+// Nothing is lost: the revisions after version stay in the history.
+func (d *VersionedDictionary) Revert(author, word string, version int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.revert(author, word, version)
+}
+
+// Undo reverts the most recent change to the word.
+func (d *VersionedDictionary) Undo(author, word string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	latest, ok := d.latest(word)
+	if !ok {
+		return ErrNotFound
+	}
+	if latest.Version == 1 {
+		return ErrNothingToUndo
+	}
+	return d.revert(author, word, latest.Version-1)
+}
+
+func (d *VersionedDictionary) revert(author, word string, version int) error {
+	target, err := d.revision(word, version)
+	if err != nil {
+		return err
+	}
+
+	latest, _ := d.latest(word)
+	if latest.Deleted == target.Deleted && latest.Definition == target.Definition {
+		return ErrAlreadyAtVersion
+	}
+
+	d.record(word, Revision{Change: ChangeRevert, Definition: target.Definition, Author: author, Deleted: target.Deleted})
+	return nil
+}
+
+// Diff compares the definitions at two versions of a word.
+func (d *VersionedDictionary) Diff(word string, from, to int) (Diff, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	before, err := d.revision(word, from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := d.revision(word, to)
+	if err != nil {
+		return nil, err
+	}
+	return DiffWords(before.Definition, after.Definition), nil
+}
+
+func (d *VersionedDictionary) latest(word string) (Revision, bool) {
+	revisions := d.history[word]
+	if len(revisions) == 0 {
+		return Revision{}, false
+	}
+	return revisions[len(revisions)-1], true
+}
+
+func (d *VersionedDictionary) revision(word string, version int) (Revision, error) {
+	revisions, ok := d.history[word]
+	if !ok {
+		return Revision{}, ErrNotFound
+	}
+	// versions start at 1 and go up by one, so version n is always at index n-1
+	if version < 1 || version > len(revisions) {
+		return Revision{}, ErrVersionNotFound
+	}
+	return revisions[version-1], nil
+}
+
+func (d *VersionedDictionary) record(word string, revision Revision) {
+	revision.Version = len(d.history[word]) + 1
+	revision.Time = d.clock.Now()
+	d.history[word] = append(d.history[word], revision)
+}
+
+type DiffOp int
+
+const (
+	DiffEqual DiffOp = iota
+	DiffInsert
+	DiffDelete
+)
+
+// DiffChunk is a run of words that were kept, added or removed.
+type DiffChunk struct {
+	Op   DiffOp
+	Text string
+}
+
+type Diff []DiffChunk
+
+// String shows the diff the way git diff --word-diff does: [-removed-] and {+added+}.
+func (d Diff) String() string {
+	parts := make([]string, len(d))
+	for i, chunk := range d {
+		switch chunk.Op {
+		case DiffInsert:
+			parts[i] = "{+" + chunk.Text + "+}"
+		case DiffDelete:
+			parts[i] = "[-" + chunk.Text + "-]"
+		default:
+			parts[i] = chunk.Text
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// DiffWords compares two definitions a word at a time. It finds the longest run of words the two
+// have in common (the longest common subsequence) and everything else was either added or removed.
+func DiffWords(before, after string) Diff {
+	a, b := strings.Fields(before), strings.Fields(after)
+
+	// common[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	var diff Diff
+	add := func(op DiffOp, word string) {
+		if n := len(diff); n > 0 && diff[n-1].Op == op {
+			diff[n-1].Text += " " + word
+			return
+		}
+		diff = append(diff, DiffChunk{op, word})
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			add(DiffEqual, a[i])
+			i++
+			j++
+		case common[i+1][j] >= common[i][j+1]:
+			add(DiffDelete, a[i])
+			i++
+		default:
+			add(DiffInsert, b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		add(DiffDelete, a[i])
+	}
+	for ; j < len(b); j++ {
+		add(DiffInsert, b[j])
+	}
+
+	return diff
+}
@@ -0,0 +1,270 @@
+package dictionary
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestVersionedDictionary(t *testing.T) {
+	t.Run("records who changed what and when", func(t *testing.T) {
+		clock := &TickingClock{}
+		dictionary := NewVersionedDictionary(clock)
+
+		assertError(t, dictionary.Add("ada", "test", "first"), nil)
+		assertError(t, dictionary.Update("grace", "test", "second", 1), nil)
+		assertError(t, dictionary.Delete("ada", "test"), nil)
+
+		history, err := dictionary.History("test")
+		assertError(t, err, nil)
+		assertRevisions(t, history, []Revision{
+			{Version: 1, Change: ChangeAdd, Definition: "first", Author: "ada", Time: clock.At(1)},
+			{Version: 2, Change: ChangeUpdate, Definition: "second", Author: "grace", Time: clock.At(2)},
+			{Version: 3, Change: ChangeDelete, Author: "ada", Time: clock.At(3), Deleted: true},
+		})
+	})
+
+	t.Run("works like a Dictionary", func(t *testing.T) {
+		dictionary := NewVersionedDictionary(&TickingClock{})
+
+		assertError(t, dictionary.Add("ada", "test", "first"), nil)
+		assertError(t, dictionary.Add("ada", "test", "again"), ErrWordExists)
+		assertError(t, dictionary.Update("ada", "unknown", "definition", AnyVersion), ErrWordDoesNotExist)
+		assertVersionedDefinition(t, dictionary, "test", "first")
+
+		assertError(t, dictionary.Delete("ada", "test"), nil)
+		assertError(t, dictionary.Delete("ada", "test"), ErrWordDoesNotExist)
+		assertError(t, dictionary.Update("ada", "test", "definition", AnyVersion), ErrWordDoesNotExist)
+		_, err := dictionary.Search("test")
+		assertError(t, err, ErrNotFound)
+		_, err = dictionary.Version("test")
+		assertError(t, err, ErrNotFound)
+
+		_, err = dictionary.History("unknown")
+		assertError(t, err, ErrNotFound)
+	})
+
+	t.Run("adding a deleted word carries on the version numbers", func(t *testing.T) {
+		dictionary := NewVersionedDictionary(&TickingClock{})
+		dictionary.Add("ada", "test", "first")
+		dictionary.Delete("ada", "test")
+
+		assertError(t, dictionary.Add("grace", "test", "back again"), nil)
+
+		version, _ := dictionary.Version("test")
+		if version != 3 {
+			t.Errorf("got version %d want 3", version)
+		}
+		assertVersionedDefinition(t, dictionary, "test", "back again")
+	})
+}
+
+func TestVersionConflicts(t *testing.T) {
+	t.Run("update with a stale version", func(t *testing.T) {
+		dictionary := NewVersionedDictionary(&TickingClock{})
+		dictionary.Add("ada", "test", "first")
+
+		seen, _ := dictionary.Version("test")
+		assertError(t, dictionary.Update("grace", "test", "grace's change", seen), nil)
+		err := dictionary.Update("ada", "test", "ada's change", seen)
+
+		var conflict *VersionConflictError
+		if !errors.As(err, &conflict) {
+			t.Fatalf("got %v want a *VersionConflictError", err)
+		}
+		if *conflict != (VersionConflictError{Word: "test", Expected: 1, Actual: 2}) {
+			t.Errorf("got %+v", conflict)
+		}
+		if !errors.Is(err, ErrVersionConflict) {
+			t.Errorf("%v should be %v", err, ErrVersionConflict)
+		}
+		assertVersionedDefinition(t, dictionary, "test", "grace's change")
+	})
+
+	t.Run("any version skips the check", func(t *testing.T) {
+		dictionary := NewVersionedDictionary(&TickingClock{})
+		dictionary.Add("ada", "test", "first")
+		dictionary.Update("ada", "test", "second", AnyVersion)
+
+		assertError(t, dictionary.Update("ada", "test", "third", AnyVersion), nil)
+	})
+
+	t.Run("only one of many concurrent editors wins", func(t *testing.T) {
+		dictionary := NewVersionedDictionary(nil)
+		dictionary.Add("ada", "test", "first")
+
+		const editors = 20
+		var wg sync.WaitGroup
+		errs := make(chan error, editors)
+		for range editors {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- dictionary.Update("editor", "test", "mine", 1)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		succeeded := 0
+		for err := range errs {
+			if err == nil {
+				succeeded++
+			} else if !errors.Is(err, ErrVersionConflict) {
+				t.Errorf("unexpected error %v", err)
+			}
+		}
+		if succeeded != 1 {
+			t.Errorf("%d updates succeeded, want exactly 1", succeeded)
+		}
+	})
+}
+
+func TestRevert(t *testing.T) {
+	newDictionary := func() *VersionedDictionary {
+		dictionary := NewVersionedDictionary(&TickingClock{})
+		dictionary.Add("ada", "test", "first")
+		dictionary.Update("grace", "test", "second", AnyVersion)
+		return dictionary
+	}
+
+	t.Run("to an earlier definition", func(t *testing.T) {
+		dictionary := newDictionary()
+
+		assertError(t, dictionary.Revert("ada", "test", 1), nil)
+
+		assertVersionedDefinition(t, dictionary, "test", "first")
+		revision, _ := dictionary.Revision("test", 3)
+		if revision.Change != ChangeRevert || revision.Author != "ada" {
+			t.Errorf("got %+v", revision)
+		}
+	})
+
+	t.Run("brings a deleted word back", func(t *testing.T) {
+		dictionary := newDictionary()
+		dictionary.Delete("ada", "test")
+
+		assertError(t, dictionary.Revert("ada", "test", 2), nil)
+		assertVersionedDefinition(t, dictionary, "test", "second")
+	})
+
+	t.Run("to a deletion deletes the word", func(t *testing.T) {
+		dictionary := newDictionary()
+		dictionary.Delete("ada", "test")
+		dictionary.Add("ada", "test", "third")
+
+		assertError(t, dictionary.Revert("ada", "test", 3), nil)
+		_, err := dictionary.Search("test")
+		assertError(t, err, ErrNotFound)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		dictionary := newDictionary()
+
+		assertError(t, dictionary.Revert("ada", "test", 0), ErrVersionNotFound)
+		assertError(t, dictionary.Revert("ada", "test", 3), ErrVersionNotFound)
+		assertError(t, dictionary.Revert("ada", "test", 2), ErrAlreadyAtVersion)
+		assertError(t, dictionary.Revert("ada", "unknown", 1), ErrNotFound)
+	})
+
+	t.Run("undo", func(t *testing.T) {
+		dictionary := newDictionary()
+
+		assertError(t, dictionary.Undo("ada", "test"), nil)
+		assertVersionedDefinition(t, dictionary, "test", "first")
+
+		// undoing the undo puts the second definition back
+		assertError(t, dictionary.Undo("ada", "test"), nil)
+		assertVersionedDefinition(t, dictionary, "test", "second")
+
+		dictionary.Add("ada", "new", "definition")
+		assertError(t, dictionary.Undo("ada", "new"), ErrNothingToUndo)
+		assertError(t, dictionary.Undo("ada", "unknown"), ErrNotFound)
+	})
+}
+
+func TestDiff(t *testing.T) {
+	t.Run("between versions", func(t *testing.T) {
+		dictionary := NewVersionedDictionary(&TickingClock{})
+		dictionary.Add("ada", "map", "a collection of keys and values")
+		dictionary.Update("ada", "map", "an unordered collection of key value pairs", AnyVersion)
+
+		diff, err := dictionary.Diff("map", 1, 2)
+
+		assertError(t, err, nil)
+		assertStrings(t, diff.String(), "[-a-] {+an unordered+} collection of [-keys and values-] {+key value pairs+}")
+
+		_, err = dictionary.Diff("map", 1, 3)
+		assertError(t, err, ErrVersionNotFound)
+	})
+
+	cases := []struct {
+		before, after string
+		want          Diff
+	}{
+		{"same words", "same words", Diff{{DiffEqual, "same words"}}},
+		{"", "all new", Diff{{DiffInsert, "all new"}}},
+		{"all gone", "", Diff{{DiffDelete, "all gone"}}},
+		{"", "", nil},
+		{"a b c", "a c", Diff{{DiffEqual, "a"}, {DiffDelete, "b"}, {DiffEqual, "c"}}},
+	}
+
+	for _, c := range cases {
+		if got := DiffWords(c.before, c.after); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("DiffWords(%q, %q) got %v want %v", c.before, c.after, got, c.want)
+		}
+	}
+}
+
+// TickingClock moves on a minute every time it's asked the time, so every change gets its own time.
+type TickingClock struct {
+	ticks int
+}
+
+var epoch = time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+
+func (c *TickingClock) Now() time.Time {
+	c.ticks++
+	return c.At(c.ticks)
+}
+
+// At is the time the clock gives out the nth time it's asked.
+func (c *TickingClock) At(tick int) time.Time {
+	return epoch.Add(time.Duration(tick) * time.Minute)
+}
+
+func assertVersionedDefinition(t testing.TB, dictionary *VersionedDictionary, word, definition string) {
+	t.Helper()
+
+	got, err := dictionary.Search(word)
+	if err != nil {
+		t.Fatal("should find word:", err)
+	}
+	assertStrings(t, got, definition)
+}
+
+func assertRevisions(t testing.TB, got, want []Revision) {
+	t.Helper()
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got revisions\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestVersionedDictionarySnapshot(t *testing.T) {
+	dictionary := NewVersionedDictionary(&TickingClock{})
+	dictionary.Add("ada", "map", "a hash table")
+	dictionary.Add("ada", "slice", "a view of an array")
+	dictionary.Update("ada", "slice", "a growable view of an array", AnyVersion)
+	dictionary.Add("ada", "gone", "soon deleted")
+	dictionary.Delete("ada", "gone")
+
+	assertWords(t, dictionary.Words(), "map", "slice")
+
+	want := Dictionary{"map": "a hash table", "slice": "a growable view of an array"}
+	if got := dictionary.Dictionary(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Config is everything about how the greeter runs that shouldn't be hard-coded.
+type Config struct {
+	Addr            string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Addr:            ":5001",
+		ReadTimeout:     5 * time.Second,
+		WriteTimeout:    10 * time.Second,
+		IdleTimeout:     60 * time.Second,
+		ShutdownTimeout: 15 * time.Second,
+	}
+}
+
+var ErrNegativeTimeout = errors.New("timeouts can't be negative")
+
+// LoadConfig starts from the defaults, then applies the environment, then the flags,
+// so a flag always wins over an environment variable.
+//
+// Rather than read os.Getenv and print usage to os.Stderr itself, LoadConfig takes
+// both as dependencies, for the same reason Greet takes an io.Writer: tests can pass in
+// their own environment and see what would have been printed.
+func LoadConfig(args []string, getenv func(string) string, output io.Writer) (Config, error) {
+	config := DefaultConfig()
+
+	if addr := getenv("GREETER_ADDR"); addr != "" {
+		config.Addr = addr
+	}
+
+	durations := []struct {
+		flag, env, usage string
+		value            *time.Duration
+	}{
+		{"read-timeout", "GREETER_READ_TIMEOUT", "how long to wait for a request to be read", &config.ReadTimeout},
+		{"write-timeout", "GREETER_WRITE_TIMEOUT", "how long a response can take to write", &config.WriteTimeout},
+		{"idle-timeout", "GREETER_IDLE_TIMEOUT", "how long to keep an idle connection open", &config.IdleTimeout},
+		{"shutdown-timeout", "GREETER_SHUTDOWN_TIMEOUT", "how long to wait for requests to finish when shutting down", &config.ShutdownTimeout},
+	}
+
+	for _, d := range durations {
+		value := getenv(d.env)
+		if value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: %w", d.env, err)
+		}
+		*d.value = duration
+	}
+
+	flags := flag.NewFlagSet("greeter", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.StringVar(&config.Addr, "addr", config.Addr, "address to listen on (env GREETER_ADDR)")
+	for _, d := range durations {
+		flags.DurationVar(d.value, d.flag, *d.value, fmt.Sprintf("%s (env %s)", d.usage, d.env))
+	}
+	if err := flags.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	for _, d := range durations {
+		if *d.value < 0 {
+			return Config{}, fmt.Errorf("%w: %s is %v", ErrNegativeTimeout, d.flag, *d.value)
+		}
+	}
+
+	return config, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	noEnv := func(string) string { return "" }
+
+	t.Run("defaults", func(t *testing.T) {
+		config, err := LoadConfig(nil, noEnv, io.Discard)
+
+		assertNoError(t, err)
+		assertConfig(t, config, DefaultConfig())
+	})
+
+	t.Run("environment", func(t *testing.T) {
+		env := map[string]string{
+			"GREETER_ADDR":             ":8080",
+			"GREETER_READ_TIMEOUT":     "1s",
+			"GREETER_SHUTDOWN_TIMEOUT": "2m",
+		}
+
+		config, err := LoadConfig(nil, func(key string) string { return env[key] }, io.Discard)
+
+		want := DefaultConfig()
+		want.Addr = ":8080"
+		want.ReadTimeout = time.Second
+		want.ShutdownTimeout = 2 * time.Minute
+		assertNoError(t, err)
+		assertConfig(t, config, want)
+	})
+
+	t.Run("flags win over the environment", func(t *testing.T) {
+		env := map[string]string{"GREETER_ADDR": ":8080", "GREETER_IDLE_TIMEOUT": "1s"}
+		args := []string{"-addr", ":9090", "-idle-timeout", "3s", "-write-timeout", "4s"}
+
+		config, err := LoadConfig(args, func(key string) string { return env[key] }, io.Discard)
+
+		want := DefaultConfig()
+		want.Addr = ":9090"
+		want.IdleTimeout = 3 * time.Second
+		want.WriteTimeout = 4 * time.Second
+		assertNoError(t, err)
+		assertConfig(t, config, want)
+	})
+
+	t.Run("bad durations", func(t *testing.T) {
+		_, err := LoadConfig(nil, func(key string) string {
+			if key == "GREETER_WRITE_TIMEOUT" {
+				return "soon"
+			}
+			return ""
+		}, io.Discard)
+		if err == nil || !strings.Contains(err.Error(), "GREETER_WRITE_TIMEOUT") {
+			t.Errorf("got %v want an error naming GREETER_WRITE_TIMEOUT", err)
+		}
+
+		_, err = LoadConfig([]string{"-read-timeout", "-1s"}, noEnv, io.Discard)
+		if !errors.Is(err, ErrNegativeTimeout) {
+			t.Errorf("got %v want %v", err, ErrNegativeTimeout)
+		}
+	})
+
+	t.Run("help", func(t *testing.T) {
+		var output strings.Builder
+
+		_, err := LoadConfig([]string{"-h"}, noEnv, &output)
+
+		if !errors.Is(err, flag.ErrHelp) {
+			t.Errorf("got %v want %v", err, flag.ErrHelp)
+		}
+		if !strings.Contains(output.String(), "GREETER_SHUTDOWN_TIMEOUT") {
+			t.Errorf("usage should mention the environment variables, got %q", output.String())
+		}
+	})
+}
+
+func assertNoError(t testing.TB, err error) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatalf("didn't expect an error but got %v", err)
+	}
+}
+
+func assertConfig(t testing.TB, got, want Config) {
+	t.Helper()
+
+	if got != want {
+		t.Errorf("got %+v want %+v", got, want)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// A Formatter writes a greeting in one particular format. Every formatter gets the
+// greeting itself from Greet, so that stays the one place that knows what a greeting says;
+// the formatters only decide how it's wrapped up.
+type Formatter interface {
+	ContentType() string
+	Format(w io.Writer, name string) error
+}
+
+type TextFormatter struct{}
+
+func (TextFormatter) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+func (TextFormatter) Format(w io.Writer, name string) error {
+	Greet(w, name)
+	return nil
+}
+
+// HTMLFormatter goes through html/template, which escapes the greeting for us.
+// Writing the name straight into the page would let anyone who could choose the name,
+// which is anyone who can send us a link, put their own <script> into it.
+type HTMLFormatter struct{}
+
+var greetingPage = template.Must(template.New("greeting").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Greeting</title></head>
+<body><p>{{.}}</p></body>
+</html>
+`))
+
+func (HTMLFormatter) ContentType() string {
+	return "text/html; charset=utf-8"
+}
+
+func (HTMLFormatter) Format(w io.Writer, name string) error {
+	return greetingPage.Execute(w, greeting(name))
+}
+
+type JSONFormatter struct{}
+
+type JSONGreeting struct {
+	Greeting string `json:"greeting"`
+	Name     string `json:"name"`
+}
+
+func (JSONFormatter) ContentType() string {
+	return "application/json"
+}
+
+func (JSONFormatter) Format(w io.Writer, name string) error {
+	return json.NewEncoder(w).Encode(JSONGreeting{Greeting: greeting(name), Name: name})
+}
+
+// greeting is Greet's output as a string, for formatters that need to put it inside something else.
+func greeting(name string) string {
+	var buffer bytes.Buffer
+	Greet(&buffer, name)
+	return buffer.String()
+}
+
+// Formatters is every format we can greet in. When a client likes several equally,
+// the first one here wins, so plain text stays the default for curl and friends.
+var Formatters = []Formatter{TextFormatter{}, HTMLFormatter{}, JSONFormatter{}}
+
+// Negotiate picks the formatter the client likes best from its Accept header, like
+// "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8". Each media range can have a quality, q,
+// from 0 to 1, and a formatter gets the quality of the most specific range that matches it,
+// so "application/json" counts over "application/*", which counts over "*/*".
+// An empty header means anything will do. If nothing is acceptable Negotiate returns nil.
+func Negotiate(accept string, formatters []Formatter) Formatter {
+	if strings.TrimSpace(accept) == "" {
+		accept = "*/*"
+	}
+	ranges := parseAccept(accept)
+
+	var best Formatter
+	bestQuality := 0.0
+	for _, formatter := range formatters {
+		mediaType, _, _ := mime.ParseMediaType(formatter.ContentType())
+		if quality := qualityOf(mediaType, ranges); quality > bestQuality {
+			best, bestQuality = formatter, quality
+		}
+	}
+	return best
+}
+
+type mediaRange struct {
+	mediaType string
+	quality   float64
+}
+
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil || quality < 0 || quality > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType, quality})
+	}
+	return ranges
+}
+
+// qualityOf finds the most specific range matching mediaType and returns its quality,
+// or 0 if there isn't one, which means not acceptable.
+func qualityOf(mediaType string, ranges []mediaRange) float64 {
+	kind, _, _ := strings.Cut(mediaType, "/")
+
+	quality, specificity := 0.0, 0
+	for _, r := range ranges {
+		var s int
+		switch r.mediaType {
+		case mediaType:
+			s = 3
+		case kind + "/*":
+			s = 2
+		case "*/*":
+			s = 1
+		default:
+			continue
+		}
+		if s > specificity {
+			quality, specificity = r.quality, s
+		}
+	}
+	return quality
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFormatters(t *testing.T) {
+	t.Run("text", func(t *testing.T) {
+		var buffer bytes.Buffer
+
+		assertNoError(t, TextFormatter{}.Format(&buffer, "Chris"))
+
+		assertBody(t, buffer.String(), "Hello, Chris")
+	})
+
+	t.Run("html escapes the name", func(t *testing.T) {
+		var buffer bytes.Buffer
+
+		assertNoError(t, HTMLFormatter{}.Format(&buffer, `<script>alert("hi")</script>`))
+
+		got := buffer.String()
+		if strings.Contains(got, "<script>") {
+			t.Errorf("name was not escaped: %s", got)
+		}
+		if !strings.Contains(got, "<p>Hello, &lt;script&gt;alert(&#34;hi&#34;)&lt;/script&gt;</p>") {
+			t.Errorf("got %s", got)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buffer bytes.Buffer
+
+		assertNoError(t, JSONFormatter{}.Format(&buffer, `Chris "CJ" <James>`))
+
+		var got JSONGreeting
+		if err := json.Unmarshal(buffer.Bytes(), &got); err != nil {
+			t.Fatalf("could not decode %q: %v", buffer.String(), err)
+		}
+		want := JSONGreeting{Greeting: `Hello, Chris "CJ" <James>`, Name: `Chris "CJ" <James>`}
+		if got != want {
+			t.Errorf("got %+v want %+v", got, want)
+		}
+	})
+}
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		accept string
+		want   Formatter
+	}{
+		{"", TextFormatter{}},
+		{"*/*", TextFormatter{}},
+		{"text/plain", TextFormatter{}},
+		{"text/html", HTMLFormatter{}},
+		{"application/json", JSONFormatter{}},
+		{"application/*", JSONFormatter{}},
+		{"text/*", TextFormatter{}},
+		// what browsers send
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", HTMLFormatter{}},
+		{"text/plain;q=0.5, application/json", JSONFormatter{}},
+		{"text/*;q=0.9, text/plain;q=0.1", HTMLFormatter{}},
+		{"application/json;q=0, */*", TextFormatter{}},
+		{"TEXT/HTML", HTMLFormatter{}},
+		{"text/html;q=abc, application/json", JSONFormatter{}},
+		{"image/png", nil},
+		{"*/*;q=0", nil},
+	}
+
+	for _, c := range cases {
+		if got := Negotiate(c.accept, Formatters); got != c.want {
+			t.Errorf("Negotiate(%q) got %T want %T", c.accept, got, c.want)
+		}
+	}
+}
+
+func TestGreeterContentNegotiation(t *testing.T) {
+	server := NewGreeterServer(DefaultConfig(), discardLogger)
+
+	cases := []struct {
+		accept, contentType, body string
+	}{
+		{"", "text/plain; charset=utf-8", "Hello, Chris"},
+		{"text/html", "text/html; charset=utf-8", "<p>Hello, Chris</p>"},
+		{"application/json", "application/json", `{"greeting":"Hello, Chris","name":"Chris"}`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.accept, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/Chris", nil)
+			request.Header.Set("Accept", c.accept)
+			response := httptest.NewRecorder()
+
+			server.Handler.ServeHTTP(response, request)
+
+			if response.Code != http.StatusOK {
+				t.Errorf("got status %d want %d", response.Code, http.StatusOK)
+			}
+			if got := response.Header().Get("Content-Type"); got != c.contentType {
+				t.Errorf("got content-type %q want %q", got, c.contentType)
+			}
+			if got := response.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("got Vary %q want Accept", got)
+			}
+			if !strings.Contains(response.Body.String(), c.body) {
+				t.Errorf("got body %q want it to contain %q", response.Body, c.body)
+			}
+		})
+	}
+
+	t.Run("nothing acceptable", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/Chris", nil)
+		request.Header.Set("Accept", "image/png")
+		response := httptest.NewRecorder()
+
+		server.Handler.ServeHTTP(response, request)
+
+		if response.Code != http.StatusNotAcceptable {
+			t.Errorf("got status %d want %d", response.Code, http.StatusNotAcceptable)
+		}
+	})
+
+	t.Run("script in the path is escaped", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/?name=%3Cscript%3Ealert(1)%3C/script%3E", nil)
+		request.Header.Set("Accept", "text/html")
+		response := httptest.NewRecorder()
+
+		server.Handler.ServeHTTP(response, request)
+
+		if strings.Contains(response.Body.String(), "<script>") {
+			t.Errorf("name was not escaped: %s", response.Body)
+		}
+	})
+}
+
+func assertBody(t testing.TB, got, want string) {
+	t.Helper()
+
+	if got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
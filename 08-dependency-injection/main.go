@@ -1,11 +1,19 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 )
 
 // We want to write a function that greets someone,
@@ -89,16 +97,74 @@ func Greet(writer io.Writer, name string) {
 
 // [4] When you write an HTTP handler, you are given an http.ResponseWriter and the http.Request that was used to make the request.
 // When you implement your server you write your response using the writer.
+//
+// The name comes from the path, /Chris, or the query, /?name=Chris, and falls back to "world".
+// The Accept header decides whether we answer in plain text, HTML or JSON.
+// Greet doesn't care where the name came from, or that it's writing to an HTTP response.
 func MyGreeterHandler(w http.ResponseWriter, r *http.Request) {
-	Greet(w, "world")
+	// the answer depends on Accept, so caches must not hand a JSON greeting to a browser
+	w.Header().Add("Vary", "Accept")
+
+	formatter := Negotiate(r.Header.Get("Accept"), Formatters)
+	if formatter == nil {
+		var supported []string
+		for _, f := range Formatters {
+			supported = append(supported, f.ContentType())
+		}
+		http.Error(w, "can greet in "+strings.Join(supported, ", "), http.StatusNotAcceptable)
+		return
+	}
+
+	w.Header().Set("Content-Type", formatter.ContentType())
+	if err := formatter.Format(w, nameFrom(r)); err != nil {
+		log.Printf("could not write greeting: %v", err)
+	}
+}
+
+func nameFrom(r *http.Request) string {
+	if name := strings.TrimSpace(r.PathValue("name")); name != "" {
+		return name
+	}
+	if name := strings.TrimSpace(r.URL.Query().Get("name")); name != "" {
+		return name
+	}
+	return "world"
 }
 
 func main() {
 	// [2] try to call the Greet function with os.Stdout
-	Greet(os.Stdout, "Baz")
+	// Greet(os.Stdout, "Baz")
 	// cannot use os.Stdout (variable of type *os.File) as *bytes.Buffer value in argument to GreetcompilerIncompatibleAssign
 
 	// [5]
 	// fmt.Fprintf allows you to pass in an io.Writer which we know both os.Stdout and bytes.Buffer implement.
-	log.Fatal(http.ListenAndServe(":5001", http.HandlerFunc(MyGreeterHandler)))
+	// log.Fatal(http.ListenAndServe(":5001", http.HandlerFunc(MyGreeterHandler)))
+
+	// [6] The address and timeouts come from flags and the environment,
+	// and Ctrl-C or a SIGTERM from whatever runs us lets in-flight requests finish before we exit.
+	config, err := LoadConfig(os.Args[1:], os.Getenv, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// structured logs on stderr, and slog.SetDefault sends the log.Printf calls there too
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	slog.SetDefault(logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	listener, err := net.Listen("tcp", config.Addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("greeting on %s", listener.Addr())
+	if err := Serve(ctx, NewGreeterServer(config, logger), listener, config.ShutdownTimeout); err != nil {
+		log.Fatal(err)
+	}
+	log.Print("shut down")
 }
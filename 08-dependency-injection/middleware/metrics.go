@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the upper bounds of the duration histogram if you don't pick your own,
+// from a quick 5ms up to a request that's taking far too long.
+var DefaultBuckets = []time.Duration{
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Metrics counts requests and how long they took, grouped by method and status code.
+// Its Middleware does the counting and, being an http.Handler itself,
+// it serves what it has counted in the Prometheus text format.
+//
+// Paths aren't part of the key on purpose: every name someone greets is a different path,
+// and we don't want one series per name. For the same reason any method that isn't
+// one of the standard ones is counted as OTHER, or a client making up methods could
+// make us keep a new series for each one forever.
+type Metrics struct {
+	// Now is the clock used to time requests, nil means time.Now. Tests swap it for one they control.
+	Now func() time.Time
+
+	buckets []time.Duration
+
+	mu     sync.Mutex
+	series map[seriesKey]*Series
+}
+
+type seriesKey struct {
+	method string
+	status int
+}
+
+// Series is everything counted for one method and status.
+// Buckets[i] is how many requests took at most the i-th bucket's duration, so they only go up,
+// and anything slower than the last bucket is only in Count.
+type Series struct {
+	Method  string
+	Status  int
+	Count   uint64
+	Sum     time.Duration
+	Buckets []Bucket
+}
+
+type Bucket struct {
+	UpperBound time.Duration
+	Count      uint64
+}
+
+// NewMetrics uses DefaultBuckets if no buckets are given.
+func NewMetrics(buckets ...time.Duration) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+
+	return &Metrics{buckets: slices.Compact(buckets), series: map[seriesKey]*Series{}}
+}
+
+// Middleware has the signature of a Middleware so it can go straight into Chain.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := m.now()
+		recorder := newResponseRecorder(w)
+
+		next.ServeHTTP(recorder, r)
+
+		m.Observe(r.Method, recorder.status, m.now().Sub(start))
+	})
+}
+
+func (m *Metrics) now() time.Time {
+	if m.Now == nil {
+		return time.Now()
+	}
+	return m.Now()
+}
+
+// OtherMethod is the method label for every request with a non-standard method.
+const OtherMethod = "OTHER"
+
+var standardMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace,
+}
+
+// Observe records one request. Middleware calls it for you.
+func (m *Metrics) Observe(method string, status int, duration time.Duration) {
+	if !slices.Contains(standardMethods, method) {
+		method = OtherMethod
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := seriesKey{method, status}
+	s, ok := m.series[key]
+	if !ok {
+		s = &Series{Method: method, Status: status, Buckets: make([]Bucket, len(m.buckets))}
+		for i, bound := range m.buckets {
+			s.Buckets[i].UpperBound = bound
+		}
+		m.series[key] = s
+	}
+
+	s.Count++
+	s.Sum += duration
+	for i := range s.Buckets {
+		if duration <= s.Buckets[i].UpperBound {
+			s.Buckets[i].Count++
+		}
+	}
+}
+
+// Snapshot returns a copy of every series, sorted by method and then status.
+func (m *Metrics) Snapshot() []Series {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := make([]Series, 0, len(m.series))
+	for _, s := range m.series {
+		copied := *s
+		copied.Buckets = slices.Clone(s.Buckets)
+		snapshot = append(snapshot, copied)
+	}
+
+	slices.SortFunc(snapshot, func(a, b Series) int {
+		if a.Method != b.Method {
+			if a.Method < b.Method {
+				return -1
+			}
+			return 1
+		}
+		return a.Status - b.Status
+	})
+	return snapshot
+}
+
+const metricName = "http_request_duration_seconds"
+
+// ServeHTTP writes the snapshot as a Prometheus histogram, so the greeter can be scraped without pulling in a client library.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("content-type", "text/plain; version=0.0.4; charset=utf-8")
+
+	fmt.Fprintf(w, "# HELP %s How long HTTP requests took to serve.\n", metricName)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", metricName)
+
+	for _, s := range m.Snapshot() {
+		labels := fmt.Sprintf(`method="%s",status="%d"`, labelEscaper.Replace(s.Method), s.Status)
+		for _, b := range s.Buckets {
+			fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", metricName, labels, formatSeconds(b.UpperBound), b.Count)
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", metricName, labels, s.Count)
+		fmt.Fprintf(w, "%s_sum{%s} %s\n", metricName, labels, formatSeconds(s.Sum))
+		fmt.Fprintf(w, "%s_count{%s} %d\n", metricName, labels, s.Count)
+	}
+}
+
+// labelEscaper escapes a label value the way the text format wants, which only
+// backslashes the backslash, double quote and new line. Go's %q would escape far more.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'g', -1, 64)
+}
@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// steppingClock moves on by the next duration every time it's read,
+// so a request timed with it takes exactly as long as the test says.
+type steppingClock struct {
+	now   time.Time
+	steps []time.Duration
+}
+
+func (c *steppingClock) Now() time.Time {
+	now := c.now
+	if len(c.steps) > 0 {
+		c.now = c.now.Add(c.steps[0])
+		c.steps = c.steps[1:]
+	}
+	return now
+}
+
+func TestMetrics(t *testing.T) {
+	t.Run("counts requests by method and status", func(t *testing.T) {
+		metrics := NewMetrics(10*time.Millisecond, 100*time.Millisecond)
+		clock := &steppingClock{steps: []time.Duration{5 * time.Millisecond, 0, 50 * time.Millisecond, 0, time.Second}}
+		metrics.Now = clock.Now
+
+		handler := metrics.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/missing" {
+				http.NotFound(w, r)
+				return
+			}
+			hello(w, r)
+		}))
+
+		serve(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+		serve(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+		serve(handler, httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+		want := []Series{
+			{
+				Method: "GET", Status: 200, Count: 2, Sum: 55 * time.Millisecond,
+				Buckets: []Bucket{{10 * time.Millisecond, 1}, {100 * time.Millisecond, 2}},
+			},
+			{
+				Method: "GET", Status: 404, Count: 1, Sum: time.Second,
+				Buckets: []Bucket{{10 * time.Millisecond, 0}, {100 * time.Millisecond, 0}},
+			},
+		}
+		if got := metrics.Snapshot(); !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v want %+v", got, want)
+		}
+	})
+
+	t.Run("snapshots don't change afterwards", func(t *testing.T) {
+		metrics := NewMetrics(time.Second)
+		metrics.Observe(http.MethodGet, 200, time.Millisecond)
+
+		snapshot := metrics.Snapshot()
+		metrics.Observe(http.MethodGet, 200, time.Millisecond)
+
+		if snapshot[0].Count != 1 || snapshot[0].Buckets[0].Count != 1 {
+			t.Errorf("snapshot changed to %+v", snapshot[0])
+		}
+	})
+
+	t.Run("buckets are sorted and deduplicated", func(t *testing.T) {
+		metrics := NewMetrics(time.Second, time.Millisecond, time.Second)
+		metrics.Observe(http.MethodGet, 200, 0)
+
+		want := []Bucket{{time.Millisecond, 1}, {time.Second, 1}}
+		if got := metrics.Snapshot()[0].Buckets; !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v want %v", got, want)
+		}
+	})
+
+	t.Run("made up methods share one series", func(t *testing.T) {
+		metrics := NewMetrics(time.Second)
+		handler := metrics.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}))
+
+		for i := range 100 {
+			serve(handler, httptest.NewRequest(fmt.Sprintf("FOO%d", i), "/", nil))
+		}
+		serve(handler, httptest.NewRequest(http.MethodDelete, "/", nil))
+
+		snapshot := metrics.Snapshot()
+		if len(snapshot) != 2 {
+			t.Fatalf("got %d series want 2: %+v", len(snapshot), snapshot)
+		}
+		if snapshot[0].Method != http.MethodDelete || snapshot[1].Method != OtherMethod || snapshot[1].Count != 100 {
+			t.Errorf("got %+v", snapshot)
+		}
+	})
+
+	t.Run("escapes label values the Prometheus way", func(t *testing.T) {
+		cases := map[string]string{
+			"GET":        "GET",
+			`say "hi"`:   `say \"hi\"`,
+			`back\slash`: `back\\slash`,
+			"new\nline":  `new\nline`,
+			"café\ttab":  "café\ttab",
+		}
+
+		for value, want := range cases {
+			if got := labelEscaper.Replace(value); got != want {
+				t.Errorf("%q: got %q want %q", value, got, want)
+			}
+		}
+	})
+
+	t.Run("serves a Prometheus histogram", func(t *testing.T) {
+		metrics := NewMetrics(10*time.Millisecond, 100*time.Millisecond)
+		metrics.Observe(http.MethodPost, 201, 20*time.Millisecond)
+		metrics.Observe(http.MethodGet, 200, 5*time.Millisecond)
+
+		response := serve(metrics, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		want := strings.Join([]string{
+			"# HELP http_request_duration_seconds How long HTTP requests took to serve.",
+			"# TYPE http_request_duration_seconds histogram",
+			`http_request_duration_seconds_bucket{method="GET",status="200",le="0.01"} 1`,
+			`http_request_duration_seconds_bucket{method="GET",status="200",le="0.1"} 1`,
+			`http_request_duration_seconds_bucket{method="GET",status="200",le="+Inf"} 1`,
+			`http_request_duration_seconds_sum{method="GET",status="200"} 0.005`,
+			`http_request_duration_seconds_count{method="GET",status="200"} 1`,
+			`http_request_duration_seconds_bucket{method="POST",status="201",le="0.01"} 0`,
+			`http_request_duration_seconds_bucket{method="POST",status="201",le="0.1"} 1`,
+			`http_request_duration_seconds_bucket{method="POST",status="201",le="+Inf"} 1`,
+			`http_request_duration_seconds_sum{method="POST",status="201"} 0.02`,
+			`http_request_duration_seconds_count{method="POST",status="201"} 1`,
+		}, "\n") + "\n"
+		assertBody(t, response, want)
+
+		if got := response.Header().Get("content-type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+			t.Errorf("got content type %q", got)
+		}
+	})
+}
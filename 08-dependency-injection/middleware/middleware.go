@@ -0,0 +1,189 @@
+// Package middleware wraps http.Handlers with the things every handler wants but none
+// should have to write: request IDs, logging, panic recovery and metrics.
+//
+// A Middleware takes a handler and returns one that does a little extra before and after
+// calling it. Chain puts them together in the order they're listed, the first outermost:
+//
+//	handler := middleware.Chain(router,
+//		middleware.RequestID(nil),
+//		middleware.Logging(logger),
+//		metrics.Middleware,
+//		middleware.Recover(logger),
+//	)
+//
+// Order matters. Recover is last so that the 500 it writes for a panic is what Logging
+// and the metrics see, and RequestID is first so everything after it can use the ID.
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps handler in middleware, so a request goes through them in the order given
+// on the way in, and in reverse on the way out.
+func Chain(handler http.Handler, middleware ...Middleware) http.Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the ID RequestID gave the request, or "" if it didn't go through RequestID.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestID gives every request an ID, keeping the one in the X-Request-ID header
+// if a proxy in front of us already set one, so a request can be traced all the way through.
+// The ID goes in the request context and back out in the response header.
+// generate makes new IDs, nil means 16 random bytes in hex.
+func RequestID(generate func() string) Middleware {
+	if generate == nil {
+		generate = randomID
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if !validRequestID(id) {
+				id = generate()
+			}
+
+			w.Header().Set(RequestIDHeader, id)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+		})
+	}
+}
+
+// validRequestID stops clients putting anything they like into our logs through the header.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if c <= ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func randomID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Logging writes one structured log line per request once it's finished, with its status,
+// size and how long it took. Server errors are logged at error level and client errors as warnings.
+func Logging(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			recorder := newResponseRecorder(w)
+
+			next.ServeHTTP(recorder, r)
+
+			level := slog.LevelInfo
+			switch {
+			case recorder.status >= 500:
+				level = slog.LevelError
+			case recorder.status >= 400:
+				level = slog.LevelWarn
+			}
+
+			logger.LogAttrs(r.Context(), level, "request",
+				slog.String("request_id", RequestIDFromContext(r.Context())),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", recorder.status),
+				slog.Int64("bytes", recorder.bytes),
+				slog.Duration("duration", time.Since(start)),
+			)
+		})
+	}
+}
+
+// Recover turns a panic in a handler into a 500 and a log line with the stack,
+// instead of net/http's default of dropping the connection with no response.
+// If the handler had already started writing its response it's too late for a 500, and the
+// client mustn't mistake half a response for a whole one, so once the panic is logged Recover
+// panics with http.ErrAbortHandler, which makes net/http cut the response off without logging it again.
+func Recover(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			recorder := newResponseRecorder(w)
+
+			defer func() {
+				err := recover()
+				if err == nil {
+					return
+				}
+				// http.ErrAbortHandler is how handlers deliberately abort a response, so let net/http handle it
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+
+				logger.LogAttrs(r.Context(), slog.LevelError, "panic",
+					slog.String("request_id", RequestIDFromContext(r.Context())),
+					slog.String("error", fmt.Sprint(err)),
+					slog.String("stack", string(debug.Stack())),
+				)
+
+				if recorder.wroteHeader {
+					panic(http.ErrAbortHandler)
+				}
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}()
+
+			next.ServeHTTP(recorder, r)
+		})
+	}
+}
+
+// responseRecorder remembers the status and size of the response as it goes past
+// to the real http.ResponseWriter.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func newResponseRecorder(w http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.NewResponseController reach the real ResponseWriter, for flushing and deadlines.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
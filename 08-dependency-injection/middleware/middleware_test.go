@@ -0,0 +1,282 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+var hello = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("Hello, world"))
+})
+
+var panics = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	panic("the greeter fell over")
+})
+
+func TestChain(t *testing.T) {
+	t.Run("runs middleware in the order declared", func(t *testing.T) {
+		var calls []string
+		trace := func(name string) Middleware {
+			return func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					calls = append(calls, name+" in")
+					next.ServeHTTP(w, r)
+					calls = append(calls, name+" out")
+				})
+			}
+		}
+		handler := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls = append(calls, "handler")
+		}), trace("first"), trace("second"), trace("third"))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		want := []string{"first in", "second in", "third in", "handler", "third out", "second out", "first out"}
+		if !slices.Equal(calls, want) {
+			t.Errorf("got %v want %v", calls, want)
+		}
+	})
+
+	t.Run("no middleware is the handler itself", func(t *testing.T) {
+		response := serve(Chain(hello), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assertBody(t, response, "Hello, world")
+	})
+}
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	handler := RequestID(func() string { return "generated" })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFromContext(r.Context())
+	}))
+
+	cases := map[string]struct {
+		header string
+		want   string
+	}{
+		"generates one":           {"", "generated"},
+		"keeps the caller's":      {"abc-123", "abc-123"},
+		"replaces spaces":         {"abc 123", "generated"},
+		"replaces control chars":  {"abc\x1b[31m", "generated"},
+		"replaces very long ones": {strings.Repeat("a", 129), "generated"},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if c.header != "" {
+				request.Header.Set(RequestIDHeader, c.header)
+			}
+
+			response := serve(handler, request)
+
+			if seen != c.want {
+				t.Errorf("handler saw %q want %q", seen, c.want)
+			}
+			if got := response.Header().Get(RequestIDHeader); got != c.want {
+				t.Errorf("response header %q want %q", got, c.want)
+			}
+		})
+	}
+
+	t.Run("random IDs by default", func(t *testing.T) {
+		handler := RequestID(nil)(hello)
+
+		first := serve(handler, httptest.NewRequest(http.MethodGet, "/", nil)).Header().Get(RequestIDHeader)
+		second := serve(handler, httptest.NewRequest(http.MethodGet, "/", nil)).Header().Get(RequestIDHeader)
+
+		if len(first) != 32 || first == second {
+			t.Errorf("want two different 32 character IDs, got %q and %q", first, second)
+		}
+	})
+
+	t.Run("no ID outside the middleware", func(t *testing.T) {
+		if got := RequestIDFromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context()); got != "" {
+			t.Errorf("got %q want no ID", got)
+		}
+	})
+}
+
+func TestLogging(t *testing.T) {
+	t.Run("logs the finished request", func(t *testing.T) {
+		var logs bytes.Buffer
+		handler := Chain(hello, RequestID(func() string { return "req-1" }), Logging(jsonLogger(&logs)))
+
+		serve(handler, httptest.NewRequest(http.MethodGet, "/Chris", nil))
+
+		entry := decodeLog(t, &logs)
+		assertField(t, entry, "level", "INFO")
+		assertField(t, entry, "msg", "request")
+		assertField(t, entry, "request_id", "req-1")
+		assertField(t, entry, "method", "GET")
+		assertField(t, entry, "path", "/Chris")
+		assertField(t, entry, "status", float64(200))
+		assertField(t, entry, "bytes", float64(len("Hello, world")))
+		if _, ok := entry["duration"]; !ok {
+			t.Error("want a duration")
+		}
+	})
+
+	t.Run("levels follow the status", func(t *testing.T) {
+		cases := map[int]string{
+			http.StatusNotFound:            "WARN",
+			http.StatusServiceUnavailable:  "ERROR",
+			http.StatusMovedPermanently:    "INFO",
+			http.StatusNotAcceptable:       "WARN",
+			http.StatusInternalServerError: "ERROR",
+		}
+
+		for status, level := range cases {
+			var logs bytes.Buffer
+			handler := Logging(jsonLogger(&logs))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(status)
+			}))
+
+			serve(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			entry := decodeLog(t, &logs)
+			assertField(t, entry, "status", float64(status))
+			assertField(t, entry, "level", level)
+		}
+	})
+}
+
+func TestRecover(t *testing.T) {
+	t.Run("a panic becomes a 500", func(t *testing.T) {
+		var logs bytes.Buffer
+		handler := Chain(panics, RequestID(func() string { return "req-1" }), Recover(jsonLogger(&logs)))
+
+		response := serve(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if response.Code != http.StatusInternalServerError {
+			t.Errorf("got status %d want %d", response.Code, http.StatusInternalServerError)
+		}
+		assertBody(t, response, "Internal Server Error\n")
+
+		entry := decodeLog(t, &logs)
+		assertField(t, entry, "msg", "panic")
+		assertField(t, entry, "request_id", "req-1")
+		assertField(t, entry, "error", "the greeter fell over")
+		if stack, _ := entry["stack"].(string); !strings.Contains(stack, "middleware_test.go") {
+			t.Errorf("want a stack pointing at the panic, got %q", stack)
+		}
+	})
+
+	t.Run("aborts a response that was already started", func(t *testing.T) {
+		var logs bytes.Buffer
+		handler := Recover(jsonLogger(&logs))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte("half"))
+			panic("too late")
+		}))
+
+		defer func() {
+			if err := recover(); err != http.ErrAbortHandler {
+				t.Errorf("got %v want %v", err, http.ErrAbortHandler)
+			}
+			entry := decodeLog(t, &logs)
+			assertField(t, entry, "msg", "panic")
+			assertField(t, entry, "error", "too late")
+		}()
+		serve(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+
+	t.Run("lets http.ErrAbortHandler through", func(t *testing.T) {
+		handler := Recover(discardLogger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}))
+
+		defer func() {
+			if err := recover(); err != http.ErrAbortHandler {
+				t.Errorf("got %v want %v", err, http.ErrAbortHandler)
+			}
+		}()
+		serve(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+
+	t.Run("the rest of the chain sees the 500", func(t *testing.T) {
+		var logs bytes.Buffer
+		metrics := NewMetrics()
+		handler := Chain(panics, Logging(jsonLogger(&logs)), metrics.Middleware, Recover(discardLogger))
+
+		serve(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		entry := decodeLog(t, &logs)
+		assertField(t, entry, "status", float64(http.StatusInternalServerError))
+		assertField(t, entry, "level", "ERROR")
+
+		snapshot := metrics.Snapshot()
+		if len(snapshot) != 1 || snapshot[0].Status != http.StatusInternalServerError {
+			t.Errorf("want one 500 counted, got %+v", snapshot)
+		}
+	})
+
+	t.Run("a real server keeps serving after a panic", func(t *testing.T) {
+		server := httptest.NewServer(Recover(discardLogger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/panic" {
+				panic("boom")
+			}
+			hello(w, r)
+		})))
+		defer server.Close()
+
+		for _, path := range []string{"/panic", "/", "/panic"} {
+			response, err := http.Get(server.URL + path)
+			if err != nil {
+				t.Fatalf("GET %s: %v", path, err)
+			}
+			response.Body.Close()
+
+			want := http.StatusOK
+			if path == "/panic" {
+				want = http.StatusInternalServerError
+			}
+			if response.StatusCode != want {
+				t.Errorf("GET %s got status %d want %d", path, response.StatusCode, want)
+			}
+		}
+	})
+}
+
+func serve(handler http.Handler, request *http.Request) *httptest.ResponseRecorder {
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	return response
+}
+
+func jsonLogger(w io.Writer) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, nil))
+}
+
+func decodeLog(t testing.TB, logs *bytes.Buffer) map[string]any {
+	t.Helper()
+
+	var entry map[string]any
+	if err := json.NewDecoder(logs).Decode(&entry); err != nil {
+		t.Fatalf("could not decode log entry from %q: %v", logs.String(), err)
+	}
+	return entry
+}
+
+func assertField(t testing.TB, entry map[string]any, key string, want any) {
+	t.Helper()
+	if got := entry[key]; got != want {
+		t.Errorf("%s: got %#v want %#v", key, got, want)
+	}
+}
+
+func assertBody(t testing.TB, response *httptest.ResponseRecorder, want string) {
+	t.Helper()
+	if got := response.Body.String(); got != want {
+		t.Errorf("got body %q want %q", got, want)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"dependency_injection_chapter/middleware"
+)
+
+// NewGreeterServer routes /{name} and /?name= to MyGreeterHandler
+// and sets the timeouts from config.
+//
+// Every request goes through the middleware chain: it gets a request ID, is logged to logger
+// and timed, and a panic in a handler becomes a 500 rather than a dropped connection.
+// The timings are served at /metrics, which wins over /{name} because it's more specific.
+func NewGreeterServer(config Config, logger *slog.Logger) *http.Server {
+	metrics := middleware.NewMetrics()
+
+	router := http.NewServeMux()
+	router.HandleFunc("GET /{$}", MyGreeterHandler)
+	router.HandleFunc("GET /{name}", MyGreeterHandler)
+	router.Handle("GET /metrics", metrics)
+
+	handler := middleware.Chain(router,
+		middleware.RequestID(nil),
+		middleware.Logging(logger),
+		metrics.Middleware,
+		middleware.Recover(logger),
+	)
+
+	return &http.Server{
+		Addr:         config.Addr,
+		Handler:      handler,
+		ReadTimeout:  config.ReadTimeout,
+		WriteTimeout: config.WriteTimeout,
+		IdleTimeout:  config.IdleTimeout,
+		ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	}
+}
+
+// Serve runs server on listener until ctx is cancelled, then shuts down gracefully:
+// it stops accepting connections and waits for requests already in flight to finish.
+// If they take longer than shutdownTimeout the remaining connections are closed and
+// the timeout error is returned.
+//
+// log.Fatal(http.ListenAndServe(...)) never gets the chance to do this: it exits
+// the program straight away and cuts off anyone halfway through a request.
+func Serve(ctx context.Context, server *http.Server, listener net.Listener, shutdownTimeout time.Duration) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	// ctx is already cancelled, so shutting down needs a context of its own
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		server.Close()
+		return err
+	}
+
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
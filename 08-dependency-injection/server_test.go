@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dependency_injection_chapter/middleware"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestGreeterServer(t *testing.T) {
+	server := NewGreeterServer(DefaultConfig(), discardLogger)
+
+	cases := map[string]string{
+		"/":                "Hello, world",
+		"/Chris":           "Hello, Chris",
+		"/?name=Elodie":    "Hello, Elodie",
+		"/Chris?name=Bob":  "Hello, Chris",
+		"/?name=":          "Hello, world",
+		"/Jos%C3%A9%20Mar": "Hello, José Mar",
+	}
+
+	for target, want := range cases {
+		t.Run(target, func(t *testing.T) {
+			response := httptest.NewRecorder()
+			server.Handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, target, nil))
+
+			if response.Code != http.StatusOK {
+				t.Errorf("got status %d want %d", response.Code, http.StatusOK)
+			}
+			if got := response.Body.String(); got != want {
+				t.Errorf("got %q want %q", got, want)
+			}
+		})
+	}
+
+	t.Run("only GET", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.Handler.ServeHTTP(response, httptest.NewRequest(http.MethodPost, "/Chris", nil))
+
+		if response.Code != http.StatusMethodNotAllowed {
+			t.Errorf("got status %d want %d", response.Code, http.StatusMethodNotAllowed)
+		}
+	})
+
+	t.Run("requests go through the middleware", func(t *testing.T) {
+		var logs strings.Builder
+		server := NewGreeterServer(DefaultConfig(), slog.New(slog.NewTextHandler(&logs, nil)))
+
+		response := httptest.NewRecorder()
+		server.Handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/Chris", nil))
+
+		id := response.Header().Get(middleware.RequestIDHeader)
+		if id == "" {
+			t.Errorf("want a %s header", middleware.RequestIDHeader)
+		}
+		if !strings.Contains(logs.String(), "request_id="+id) || !strings.Contains(logs.String(), "path=/Chris") {
+			t.Errorf("request was not logged, got %q", logs.String())
+		}
+
+		response = httptest.NewRecorder()
+		server.Handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		want := `http_request_duration_seconds_count{method="GET",status="200"} 1`
+		if !strings.Contains(response.Body.String(), want) {
+			t.Errorf("metrics do not contain %q, got\n%s", want, response.Body.String())
+		}
+	})
+}
+
+func TestServe(t *testing.T) {
+	t.Run("finishes in-flight requests before shutting down", func(t *testing.T) {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+			Greet(w, "slow")
+		})}
+
+		listener := listen(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		served := make(chan error, 1)
+		go func() { served <- Serve(ctx, server, listener, time.Minute) }()
+
+		responses := make(chan string, 1)
+		go func() {
+			response, err := http.Get("http://" + listener.Addr().String())
+			if err != nil {
+				responses <- err.Error()
+				return
+			}
+			defer response.Body.Close()
+			body, _ := io.ReadAll(response.Body)
+			responses <- string(body)
+		}()
+
+		<-started
+		cancel()
+
+		select {
+		case err := <-served:
+			t.Fatalf("Serve returned %v while a request was still running", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		// new connections are turned away while we wait
+		if _, err := net.Dial("tcp", listener.Addr().String()); err == nil {
+			t.Error("should not accept connections while shutting down")
+		}
+
+		close(release)
+
+		if got := <-responses; got != "Hello, slow" {
+			t.Errorf("in-flight request got %q want %q", got, "Hello, slow")
+		}
+		if err := <-served; err != nil {
+			t.Errorf("got %v want a clean shutdown", err)
+		}
+	})
+
+	t.Run("gives up on requests that outlast the shutdown timeout", func(t *testing.T) {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		defer close(release)
+		server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+		})}
+
+		listener := listen(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		served := make(chan error, 1)
+		go func() { served <- Serve(ctx, server, listener, 10*time.Millisecond) }()
+
+		go http.Get("http://" + listener.Addr().String())
+		<-started
+		cancel()
+
+		if err := <-served; err != context.DeadlineExceeded {
+			t.Errorf("got %v want %v", err, context.DeadlineExceeded)
+		}
+	})
+
+	t.Run("reports errors serving", func(t *testing.T) {
+		listener := listen(t)
+		listener.Close()
+
+		err := Serve(context.Background(), &http.Server{}, listener, time.Second)
+
+		if err == nil {
+			t.Error("want an error serving on a closed listener")
+		}
+	})
+}
+
+func listen(t testing.TB) net.Listener {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return listener
+}